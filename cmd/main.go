@@ -1,10 +1,26 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/Ademayowa/job-board/internal/config"
 	db "github.com/Ademayowa/job-board/internal/database"
 	"github.com/Ademayowa/job-board/internal/handlers"
+	"github.com/Ademayowa/job-board/internal/logging"
+	"github.com/Ademayowa/job-board/internal/ratelimit"
+	"github.com/Ademayowa/job-board/internal/scheduler"
+	"github.com/Ademayowa/job-board/internal/server"
+	"github.com/Ademayowa/job-board/internal/spam"
+	"github.com/Ademayowa/job-board/internal/tracing"
+	"github.com/Ademayowa/job-board/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -12,19 +28,132 @@ import (
 
 func main() {
 	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		println("No .env file found, using default environment variables.")
+	envErr := godotenv.Load()
+
+	// "config print" shows the effective configuration and exits
+	args := os.Args[1:]
+	printConfig := len(args) >= 2 && args[0] == "config" && args[1] == "print"
+	if printConfig {
+		args = args[2:]
+	}
+
+	cfg, err := config.Load(args, os.Getenv)
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+
+	if printConfig {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(cfg.Redacted()); err != nil {
+			fatal("could not print configuration", err)
+		}
+		return
+	}
+
+	// Log JSON lines to stderr. Packages using the log package end up here too.
+	slog.SetDefault(logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format))
+	if envErr != nil {
+		slog.Info("no .env file found, using the environment as is")
+	}
+	if cfg.Log.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// Trace requests and the queries they make
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, os.Stdout)
+	if err != nil {
+		fatal("could not set up tracing", err)
+	}
+
+	db.InitDB(cfg.Database)
+
+	// Stop on Ctrl+C or when the platform asks the process to terminate
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background workers are stopped in the reverse order they are started
+	var workers server.Workers
+	var deps handlers.Dependencies
+
+	// Score new jobs for spam, picking up changes to the rules file
+	spamRules, err := spam.LoadRules(cfg.Spam.RulesFile)
+	if err != nil {
+		fatal("could not load spam rules", err)
+	}
+	workers.Start("spam rules watcher", func(ctx context.Context) {
+		spamRules.Watch(ctx, cfg.Spam.ReloadInterval.Duration())
+	})
+	deps.SpamFilter = spam.NewPipeline(spamRules)
+
+	// Deliver job events to webhook subscribers in the background
+	dispatcher := webhooks.NewDispatcher()
+	dispatcher.Client = webhooks.NewClient(cfg.Webhooks.Timeout.Duration())
+	if cfg.Webhooks.AllowPrivateTargets {
+		dispatcher.Client = &http.Client{Timeout: cfg.Webhooks.Timeout.Duration()}
+	}
+	dispatcher.PollInterval = cfg.Webhooks.PollInterval.Duration()
+	dispatcher.MaxAttempts = cfg.Webhooks.MaxAttempts
+	workers.Start("webhook dispatcher", dispatcher.Run)
+
+	// Publish scheduled jobs when their time comes. Stopped before the
+	// dispatcher, so the events of the last jobs it publishes are queued.
+	publisher := scheduler.NewPublisher()
+	publisher.Interval = cfg.Scheduler.Interval.Duration()
+	workers.Start("scheduled job publisher", publisher.Run)
+
+	// Share rate limits between servers through Redis if configured
+	if cfg.RateLimit.Store == "redis" {
+		store, err := ratelimit.DialRedis(cfg.RateLimit.RedisURL)
+		if err != nil {
+			fatal("could not set up rate limiting", err)
+		}
+		deps.RateLimitStore = store
+	}
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("could not set trusted proxies", err)
 	}
+	var shuttingDown atomic.Bool
+	deps.ShuttingDown = &shuttingDown
+	handlers.RegisterRoutes(router, cfg, deps)
+
+	// Serve until told to stop. Readiness fails first so load balancers
+	// move traffic elsewhere, then in-flight requests get to finish.
+	serving := server.Drain(ctx, cfg.Server.DrainDelay.Duration(), func() {
+		shuttingDown.Store(true)
+		// A second signal kills the process without waiting
+		stop()
+	})
 
-	db.InitDB()
+	slog.Info("listening", "addr", cfg.Server.Addr(), "tls", cfg.Server.TLS.Enabled())
+	serveErr := server.Run(serving, router, cfg.Server)
+	if serveErr != nil {
+		slog.Error("server stopped", "error", serveErr)
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Nothing uses the database once the requests and workers are done
+	workers.Stop(cfg.Server.ShutdownTimeout.Duration())
+	if err := db.CloseDB(); err != nil {
+		slog.Error("could not close database", "error", err)
 	}
 
-	server := gin.Default()
-	handlers.RegisterRoutes(server)
+	// Send the last spans before exiting
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("could not flush traces", "error", err)
+	}
+	cancel()
+	slog.Info("shut down")
+
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
 
-	server.Run(":" + port)
+// fatal logs err and exits
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
 }
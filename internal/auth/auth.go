@@ -0,0 +1,107 @@
+// Package auth establishes who is making a request. Users are
+// authenticated by the gateway in front of the API, which passes their
+// identity on in headers. Those headers are only believed on requests that
+// prove they came through the gateway by carrying its shared secret, so
+// clients calling the API directly can't claim to be someone else.
+// Clients such as partner integrations may also send one of the
+// configured API keys, which names them without making them a user.
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers read by Middleware
+const (
+	// GatewayHeader carries the secret shared with the gateway
+	GatewayHeader = "X-Gateway-Secret"
+	// UserHeader and RoleHeader carry the user the gateway authenticated
+	// and the role they act in
+	UserHeader = "X-User-ID"
+	RoleHeader = "X-User-Role"
+	// APIKeyHeader carries an API key
+	APIKeyHeader = "X-API-Key"
+)
+
+// identityKey is the key under which the identity of a request is kept
+const identityKey = "auth_identity"
+
+// Identity is what is known for certain about who made a request
+type Identity struct {
+	// User and Role are set when the gateway authenticated the user.
+	// Role is as sent by the gateway and may be empty.
+	User string
+	Role string
+	// APIKey identifies the API key the request was made with, if it
+	// was one of the configured keys. It is a digest of the key, so the
+	// key itself doesn't spread any further.
+	APIKey string
+}
+
+// Middleware records the identity of every request. Identity headers
+// are ignored unless the request carries gatewaySecret, and are always
+// ignored when it is empty. Requests with an API key that isn't one of
+// apiKeys are rejected.
+func Middleware(gatewaySecret string, apiKeys []string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var identity Identity
+
+		if fromGateway(context.GetHeader(GatewayHeader), gatewaySecret) {
+			identity.User = strings.TrimSpace(context.GetHeader(UserHeader))
+			identity.Role = strings.ToLower(strings.TrimSpace(context.GetHeader(RoleHeader)))
+		}
+
+		if key := strings.TrimSpace(context.GetHeader(APIKeyHeader)); key != "" {
+			if !knownKey(key, apiKeys) {
+				response.Error(context, http.StatusUnauthorized, "invalid API key")
+				context.Abort()
+				return
+			}
+			identity.APIKey = digest(key)
+		}
+
+		context.Set(identityKey, identity)
+		context.Next()
+	}
+}
+
+// FromContext returns the identity of the request. It is empty for
+// anonymous requests and when Middleware didn't run.
+func FromContext(context *gin.Context) Identity {
+	value, _ := context.Get(identityKey)
+	identity, _ := value.(Identity)
+	return identity
+}
+
+// knownKey reports whether key is one of keys, in constant time
+func knownKey(key string, keys []string) bool {
+	known := false
+	for _, k := range keys {
+		if k != "" && subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			known = true
+		}
+	}
+	return known
+}
+
+// digest names an API key without revealing it
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fromGateway reports whether secret is the gateway's, in constant time
+func fromGateway(secret, gatewaySecret string) bool {
+	if gatewaySecret == "" || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(gatewaySecret)) == 1
+}
@@ -1,11 +1,467 @@
 package config
 
-// Backend API Routes
-const (
-	JobsAPI = "/jobs"
-)
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-// Frontend Routes
-const (
-	JobDetailsPage = "/job"
+	"github.com/Ademayowa/job-board/internal/ratelimit"
+	"github.com/Ademayowa/job-board/internal/validation"
 )
+
+// Config is every setting of the server. It is loaded once at startup by
+// Load and passed to the parts of the server that need it.
+type Config struct {
+	Server     Server     `json:"server"`
+	Database   Database   `json:"database"`
+	Site       Site       `json:"site"`
+	Webhooks   Webhooks   `json:"webhooks"`
+	Scheduler  Scheduler  `json:"scheduler"`
+	Spam       Spam       `json:"spam"`
+	Moderation Moderation `json:"moderation"`
+	RateLimit  RateLimit  `json:"rate_limit"`
+	Log        Log        `json:"log"`
+	Tracing    Tracing    `json:"tracing"`
+	API        API        `json:"api"`
+	GraphQL    GraphQL    `json:"graphql"`
+	Auth       Auth       `json:"auth"`
+}
+
+// Server is where the API listens and who may call it from a browser
+type Server struct {
+	Host           string   `json:"host"`
+	Port           int      `json:"port"`
+	CORSOrigins    []string `json:"cors_origins"`
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// ReadHeaderTimeout and ReadTimeout limit how long a client may take
+	// to send a request, WriteTimeout how long a response may take, and
+	// IdleTimeout how long a kept-alive connection waits for the next one
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout"`
+	// DrainDelay is how long the server keeps serving with /readyz
+	// failing before it stops, giving load balancers time to notice
+	DrainDelay Duration `json:"drain_delay"`
+	// ShutdownTimeout is how long in-flight requests get to finish after
+	// the server stops accepting connections
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+
+	TLS TLS `json:"tls"`
+}
+
+// TLS serves HTTPS when a certificate and key are given. The files are
+// checked every ReloadInterval, so renewed certificates are picked up
+// without a restart.
+type TLS struct {
+	CertFile       string   `json:"cert_file"`
+	KeyFile        string   `json:"key_file"`
+	ReloadInterval Duration `json:"reload_interval"`
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Database is the SQLite database and its connection pool
+type Database struct {
+	Path         string `json:"path"`
+	MaxOpenConns int    `json:"max_open_conns"`
+	MaxIdleConns int    `json:"max_idle_conns"`
+	// QueryTimeout bounds each call into the data layer, so a slow query
+	// gives up instead of holding a connection
+	QueryTimeout Duration `json:"query_timeout"`
+	// ExportTimeout bounds streaming every job out of the database, which
+	// takes longer than one query but mustn't hold a connection forever.
+	// Exports and the sitemap may take this long to send, whatever the
+	// server's WriteTimeout.
+	ExportTimeout Duration `json:"export_timeout"`
+}
+
+// Site describes the job board to feeds, search engines and shared links
+type Site struct {
+	Name             string `json:"name"`
+	JobDetailsPage   string `json:"job_details_page"`
+	OrganizationName string `json:"organization_name"`
+	SalaryCurrency   string `json:"salary_currency"`
+	SalaryUnit       string `json:"salary_unit"`
+}
+
+// Webhooks controls delivery of job events to subscribers
+type Webhooks struct {
+	PollInterval Duration `json:"poll_interval"`
+	Timeout      Duration `json:"timeout"`
+	MaxAttempts  int      `json:"max_attempts"`
+	// AllowPrivateTargets lets webhooks use plain http and reach loopback
+	// and private addresses, for local development only
+	AllowPrivateTargets bool `json:"allow_private_targets"`
+}
+
+// Scheduler controls publishing of scheduled jobs
+type Scheduler struct {
+	Interval Duration `json:"interval"`
+}
+
+// Spam controls where spam rules are read from and how often
+type Spam struct {
+	RulesFile      string   `json:"rules_file"`
+	ReloadInterval Duration `json:"reload_interval"`
+}
+
+// Moderation controls how near-duplicate postings are handled
+type Moderation struct {
+	DuplicatePolicy string `json:"duplicate_policy"`
+}
+
+// RateLimit chooses where rate limits are counted and sets them per route
+type RateLimit struct {
+	// Store is "memory" or "redis"
+	Store    string `json:"store"`
+	RedisURL string `json:"redis_url" secret:"true"`
+	// Routes are keyed by method and route pattern without the API
+	// version, e.g. "POST /jobs" limits /jobs and /api/v1/jobs together.
+	// Routes not listed, or with zero requests, are not limited.
+	Routes map[string]Limit `json:"routes"`
+}
+
+// API controls the unversioned routes, e.g. /jobs, that predate /api/v1
+type API struct {
+	// LegacyRoutes keeps serving them as deprecated aliases of /api/v1
+	LegacyRoutes bool `json:"legacy_routes"`
+	// LegacyDeprecated and LegacySunset are announced on every legacy
+	// response: when the routes were deprecated and when they go away
+	LegacyDeprecated time.Time `json:"legacy_deprecated"`
+	LegacySunset     time.Time `json:"legacy_sunset"`
+}
+
+// Auth decides when the API believes who a request says it is from
+type Auth struct {
+	// GatewaySecret is shared with the gateway that authenticates users.
+	// The gateway sends it in X-Gateway-Secret along with X-User-ID and
+	// X-User-Role; requests without it are anonymous whatever headers they
+	// carry. When it is empty every request is anonymous.
+	GatewaySecret string `json:"gateway_secret" secret:"true"`
+	// APIKeys are the keys clients may send in X-API-Key. A request with
+	// any other key is rejected.
+	APIKeys []string `json:"api_keys" secret:"true"`
+}
+
+// GraphQL bounds the queries /graphql runs, so one request can't ask
+// for more than the database can answer
+type GraphQL struct {
+	// MaxDepth is how deeply fields may be nested
+	MaxDepth int `json:"max_depth"`
+	// MaxComplexity bounds the fields a query may resolve. A field under a
+	// list counts once for every item the list may return.
+	MaxComplexity int `json:"max_complexity"`
+}
+
+// Log controls what is logged and how
+type Log struct {
+	// Level is "debug", "info", "warn" or "error"
+	Level string `json:"level"`
+	// Format is "json" or "text"
+	Format string `json:"format"`
+}
+
+// Tracing controls where OpenTelemetry traces are sent
+type Tracing struct {
+	// Exporter is "none", "stdout" for local debugging, or "otlp"
+	Exporter string `json:"exporter"`
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318
+	Endpoint    string `json:"endpoint"`
+	ServiceName string `json:"service_name"`
+	// SampleRatio is the share of new traces recorded, from 0 to 1.
+	// Requests that arrive with a sampled parent trace are always recorded.
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// Limit allows Requests requests per Per period
+type Limit struct {
+	Requests int      `json:"requests"`
+	Per      Duration `json:"per"`
+}
+
+// Limits returns the route limits in the form the middleware takes
+func (r RateLimit) Limits() map[string]ratelimit.Limit {
+	limits := make(map[string]ratelimit.Limit, len(r.Routes))
+	for route, limit := range r.Routes {
+		limits[route] = ratelimit.Limit{Requests: limit.Requests, Per: limit.Per.Duration()}
+	}
+	return limits
+}
+
+// Addr is the address the server listens on
+func (s Server) Addr() string {
+	return s.Host + ":" + strconv.Itoa(s.Port)
+}
+
+// Default returns the settings used for anything not configured
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:              8080,
+			CORSOrigins:       []string{"http://localhost:8080"},
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			ReadTimeout:       Duration(30 * time.Second),
+			WriteTimeout:      Duration(60 * time.Second),
+			IdleTimeout:       Duration(2 * time.Minute),
+			DrainDelay:        Duration(5 * time.Second),
+			ShutdownTimeout:   Duration(20 * time.Second),
+			TLS:               TLS{ReloadInterval: Duration(time.Minute)},
+		},
+		Database: Database{
+			Path:          "job.db",
+			MaxOpenConns:  10,
+			MaxIdleConns:  5,
+			QueryTimeout:  Duration(5 * time.Second),
+			ExportTimeout: Duration(5 * time.Minute),
+		},
+		Site: Site{
+			Name:             "Job Board",
+			JobDetailsPage:   "/job",
+			OrganizationName: "Job Board",
+			SalaryCurrency:   "USD",
+			SalaryUnit:       "YEAR",
+		},
+		Webhooks: Webhooks{
+			PollInterval: Duration(5 * time.Second),
+			Timeout:      Duration(10 * time.Second),
+			MaxAttempts:  8,
+		},
+		Scheduler: Scheduler{Interval: Duration(time.Minute)},
+		Spam: Spam{
+			RulesFile:      "spam_rules.json",
+			ReloadInterval: Duration(30 * time.Second),
+		},
+		Moderation: Moderation{DuplicatePolicy: "warn"},
+		RateLimit: RateLimit{
+			Store: "memory",
+			Routes: map[string]Limit{
+				"GET /jobs":                {Requests: 300, Per: Duration(time.Minute)},
+				"POST /jobs":               {Requests: 30, Per: Duration(time.Minute)},
+				"POST /jobs/import":        {Requests: 5, Per: Duration(time.Minute)},
+				"GET /jobs/export":         {Requests: 10, Per: Duration(time.Minute)},
+				"GET /jobs/recent":         {Requests: 300, Per: Duration(time.Minute)},
+				"GET /jobs/highest-salary": {Requests: 300, Per: Duration(time.Minute)},
+				"GET /jobs/feed.rss":       {Requests: 60, Per: Duration(time.Minute)},
+				"GET /jobs/feed.atom":      {Requests: 60, Per: Duration(time.Minute)},
+				"GET /jobs/:id":            {Requests: 600, Per: Duration(time.Minute)},
+				"PUT /jobs/:id":            {Requests: 60, Per: Duration(time.Minute)},
+				"POST /jobs/:id/reports":   {Requests: 10, Per: Duration(time.Hour)},
+				"POST /graphql":            {Requests: 300, Per: Duration(time.Minute)},
+			},
+		},
+		Log: Log{Level: "info", Format: "json"},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "job-board",
+			SampleRatio: 1,
+		},
+		API: API{
+			LegacyRoutes:     true,
+			LegacyDeprecated: time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+			LegacySunset:     time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+		},
+		GraphQL: GraphQL{MaxDepth: 10, MaxComplexity: 1000},
+	}
+}
+
+// minSecretLength is the shortest shared secret accepted
+const minSecretLength = 32
+
+// minLength rejects strings shorter than min characters
+func minLength(min int) validation.Rule[string] {
+	return func(value string) (string, string, bool) {
+		return validation.CodeTooSmall, "must be at least " + strconv.Itoa(min) + " characters", len(value) >= min
+	}
+}
+
+// positive rejects numbers that are zero or less
+func positive() validation.Rule[float64] {
+	return func(value float64) (string, string, bool) {
+		return validation.CodeTooSmall, "must be greater than 0", value > 0
+	}
+}
+
+// Validate checks that the settings make sense together and returns
+// validation.Errors naming every bad setting
+func (c Config) Validate() error {
+	checks := []validation.Check{
+		validation.Field("server.port", float64(c.Server.Port), validation.Min(1), validation.Max(65535)),
+		validation.Field("server.cors_origins", c.Server.CORSOrigins, validation.NotEmpty[string]()),
+		validation.Each("server.cors_origins", c.Server.CORSOrigins, validation.Required(), origin()),
+		validation.Field("server.read_header_timeout", c.Server.ReadHeaderTimeout.Duration().Seconds(), positive()),
+		validation.Field("server.read_timeout", c.Server.ReadTimeout.Duration().Seconds(), positive()),
+		validation.Field("server.write_timeout", c.Server.WriteTimeout.Duration().Seconds(), positive()),
+		validation.Field("server.idle_timeout", c.Server.IdleTimeout.Duration().Seconds(), positive()),
+		validation.Field("server.drain_delay", c.Server.DrainDelay.Duration().Seconds(), validation.Min(0)),
+		validation.Field("server.shutdown_timeout", c.Server.ShutdownTimeout.Duration().Seconds(), positive()),
+		validation.Field("database.path", c.Database.Path, validation.Required()),
+		validation.Field("database.max_open_conns", float64(c.Database.MaxOpenConns), positive()),
+		validation.Field("database.max_idle_conns", float64(c.Database.MaxIdleConns), validation.Min(0), validation.Max(float64(c.Database.MaxOpenConns))),
+		validation.Field("database.query_timeout", c.Database.QueryTimeout.Duration().Seconds(), positive()),
+		validation.Field("database.export_timeout", c.Database.ExportTimeout.Duration().Seconds(), positive()),
+		validation.Field("site.job_details_page", c.Site.JobDetailsPage, validation.Required()),
+		validation.Field("webhooks.poll_interval", c.Webhooks.PollInterval.Duration().Seconds(), positive()),
+		validation.Field("webhooks.timeout", c.Webhooks.Timeout.Duration().Seconds(), positive()),
+		validation.Field("webhooks.max_attempts", float64(c.Webhooks.MaxAttempts), positive()),
+		validation.Field("scheduler.interval", c.Scheduler.Interval.Duration().Seconds(), positive()),
+		validation.Field("spam.reload_interval", c.Spam.ReloadInterval.Duration().Seconds(), positive()),
+		validation.Field("moderation.duplicate_policy", c.Moderation.DuplicatePolicy, validation.OneOf("warn", "reject")),
+		validation.Field("rate_limit.store", c.RateLimit.Store, validation.OneOf("memory", "redis")),
+		validation.Field("log.level", c.Log.Level, validation.OneOf("debug", "info", "warn", "error")),
+		validation.Field("log.format", c.Log.Format, validation.OneOf("json", "text")),
+		validation.Field("tracing.exporter", c.Tracing.Exporter, validation.OneOf("none", "stdout", "otlp")),
+		validation.Field("tracing.service_name", c.Tracing.ServiceName, validation.Required()),
+		validation.Field("tracing.sample_ratio", c.Tracing.SampleRatio, validation.Min(0), validation.Max(1)),
+		validation.Field("graphql.max_depth", float64(c.GraphQL.MaxDepth), positive()),
+		validation.Field("graphql.max_complexity", float64(c.GraphQL.MaxComplexity), positive()),
+	}
+
+	if c.Server.TLS.Enabled() {
+		checks = append(checks,
+			validation.Field("server.tls.cert_file", c.Server.TLS.CertFile, validation.Required()),
+			validation.Field("server.tls.key_file", c.Server.TLS.KeyFile, validation.Required()),
+			validation.Field("server.tls.reload_interval", c.Server.TLS.ReloadInterval.Duration().Seconds(), positive()),
+		)
+	}
+
+	// A short secret could be guessed, letting anyone act as any user
+	if c.Auth.GatewaySecret != "" {
+		checks = append(checks, validation.Field("auth.gateway_secret", c.Auth.GatewaySecret, minLength(minSecretLength)))
+	}
+	checks = append(checks, validation.Each("auth.api_keys", c.Auth.APIKeys, minLength(minSecretLength)))
+
+	if c.Tracing.Exporter == "otlp" {
+		checks = append(checks, validation.Field("tracing.endpoint", c.Tracing.Endpoint, validation.Required(), validation.URL()))
+	}
+
+	if c.API.LegacyRoutes {
+		checks = append(checks, validation.Field("api.legacy_sunset", c.API.LegacySunset, after(c.API.LegacyDeprecated)))
+	}
+
+	if c.RateLimit.Store == "redis" {
+		checks = append(checks, validation.Field("rate_limit.redis_url", c.RateLimit.RedisURL, validation.Required()))
+	}
+
+	routes := make([]string, 0, len(c.RateLimit.Routes))
+	for route := range c.RateLimit.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	for _, route := range routes {
+		limit := c.RateLimit.Routes[route]
+		field := "rate_limit.routes[" + route + "]"
+		checks = append(checks,
+			validation.Field(field, route, routePattern()),
+			validation.Field(field+".requests", float64(limit.Requests), validation.Min(0)),
+			validation.Field(field+".per", limit.Per.Duration().Seconds(), positive()),
+		)
+	}
+
+	return validation.Validate(checks...)
+}
+
+// origin accepts "*" or a scheme and host such as https://example.com
+func origin() validation.Rule[string] {
+	return func(value string) (string, string, bool) {
+		if value == "*" {
+			return "", "", true
+		}
+		parsed, err := url.Parse(value)
+		valid := err == nil && parsed.Scheme != "" && parsed.Host != "" && (parsed.Path == "" || parsed.Path == "/")
+		return validation.CodeInvalidURL, "must be * or a scheme and host", valid
+	}
+}
+
+// after accepts times later than start
+func after(start time.Time) validation.Rule[time.Time] {
+	return func(value time.Time) (string, string, bool) {
+		return validation.CodeTooSmall, "must be after " + start.Format(time.RFC3339), value.After(start)
+	}
+}
+
+// routePattern accepts a method followed by a path, e.g. "GET /jobs/:id"
+func routePattern() validation.Rule[string] {
+	return func(value string) (string, string, bool) {
+		method, path, ok := strings.Cut(value, " ")
+		valid := ok && method == strings.ToUpper(method) && method != "" && strings.HasPrefix(path, "/")
+		return validation.CodeNotAllowed, "must be a method and a path, e.g. \"GET /jobs\"", valid
+	}
+}
+
+// Redacted returns a copy of the config that is safe to print, with every
+// field tagged secret:"true" masked
+func (c Config) Redacted() Config {
+	redacted := c
+	redact(reflect.ValueOf(&redacted).Elem())
+	return redacted
+}
+
+// redact masks the secret string and string list fields of a struct,
+// recursing into nested structs. Lists are replaced rather than changed,
+// as the original config shares them.
+func redact(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		secret := v.Type().Field(i).Tag.Get("secret") == "true"
+		switch {
+		case field.Kind() == reflect.Struct:
+			redact(field)
+		case field.Kind() == reflect.String && secret && field.String() != "":
+			field.SetString("[REDACTED]")
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String && secret && field.Len() > 0:
+			masked := reflect.MakeSlice(field.Type(), field.Len(), field.Len())
+			for j := 0; j < masked.Len(); j++ {
+				masked.Index(j).SetString("[REDACTED]")
+			}
+			field.Set(masked)
+		}
+	}
+}
+
+// Duration is a time.Duration written as a string such as "30s" or "5m"
+// in config files
+type Duration time.Duration
+
+// Duration returns d as a time.Duration
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	return d.UnmarshalText([]byte(text))
+}
+
+// UnmarshalText parses a duration such as "30s", as used by environment
+// variables and flags
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultFile is read when no config file is named. It is optional.
+const DefaultFile = "config.json"
+
+// setting is one value that can be set by an environment variable and a
+// command-line flag
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	apply func(config *Config, value string) error
+}
+
+// settings lists everything that can be set from the environment or the
+// command line. Anything else is set in the config file.
+var settings = []setting{
+	{"HOST", "host", "address to listen on", func(c *Config, v string) error {
+		c.Server.Host = v
+		return nil
+	}},
+	{"PORT", "port", "port to listen on", func(c *Config, v string) error {
+		port, err := strconv.Atoi(v)
+		c.Server.Port = port
+		return err
+	}},
+	{"CORS_ORIGINS", "cors-origins", "comma-separated origins allowed to call the API", func(c *Config, v string) error {
+		c.Server.CORSOrigins = splitList(v)
+		return nil
+	}},
+	{"TRUSTED_PROXIES", "trusted-proxies", "comma-separated proxies trusted to set the client IP", func(c *Config, v string) error {
+		c.Server.TrustedProxies = splitList(v)
+		return nil
+	}},
+	{"DRAIN_DELAY", "drain-delay", "how long to keep serving with readiness failing before shutting down, e.g. 5s", func(c *Config, v string) error {
+		return c.Server.DrainDelay.UnmarshalText([]byte(v))
+	}},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long in-flight requests get to finish on shutdown, e.g. 20s", func(c *Config, v string) error {
+		return c.Server.ShutdownTimeout.UnmarshalText([]byte(v))
+	}},
+	{"TLS_CERT_FILE", "tls-cert", "path of the TLS certificate, enables HTTPS", func(c *Config, v string) error {
+		c.Server.TLS.CertFile = v
+		return nil
+	}},
+	{"TLS_KEY_FILE", "tls-key", "path of the TLS private key", func(c *Config, v string) error {
+		c.Server.TLS.KeyFile = v
+		return nil
+	}},
+	{"LEGACY_ROUTES", "legacy-routes", "serve the unversioned routes as deprecated aliases of /api/v1: true or false", func(c *Config, v string) error {
+		legacy, err := strconv.ParseBool(v)
+		c.API.LegacyRoutes = legacy
+		return err
+	}},
+	{"GRAPHQL_MAX_DEPTH", "graphql-max-depth", "how deeply the fields of a GraphQL query may be nested", func(c *Config, v string) error {
+		depth, err := strconv.Atoi(v)
+		c.GraphQL.MaxDepth = depth
+		return err
+	}},
+	{"GRAPHQL_MAX_COMPLEXITY", "graphql-max-complexity", "how many fields a GraphQL query may resolve, counting list items", func(c *Config, v string) error {
+		complexity, err := strconv.Atoi(v)
+		c.GraphQL.MaxComplexity = complexity
+		return err
+	}},
+	{"GATEWAY_SECRET", "gateway-secret", "secret the gateway sends with the identity of the user it authenticated", func(c *Config, v string) error {
+		c.Auth.GatewaySecret = v
+		return nil
+	}},
+	{"API_KEYS", "api-keys", "comma-separated keys clients may send in X-API-Key", func(c *Config, v string) error {
+		c.Auth.APIKeys = splitList(v)
+		return nil
+	}},
+	{"DATABASE_PATH", "db", "path of the SQLite database", func(c *Config, v string) error {
+		c.Database.Path = v
+		return nil
+	}},
+	{"DB_QUERY_TIMEOUT", "db-query-timeout", "how long a database call may take before it is cancelled, e.g. 5s", func(c *Config, v string) error {
+		return c.Database.QueryTimeout.UnmarshalText([]byte(v))
+	}},
+	{"DB_EXPORT_TIMEOUT", "db-export-timeout", "how long streaming an export out of the database may take, e.g. 5m", func(c *Config, v string) error {
+		return c.Database.ExportTimeout.UnmarshalText([]byte(v))
+	}},
+	{"SITE_NAME", "site-name", "name of the job board shown in feeds", func(c *Config, v string) error {
+		c.Site.Name = v
+		return nil
+	}},
+	{"SPAM_RULES_FILE", "spam-rules", "path of the spam rules file", func(c *Config, v string) error {
+		c.Spam.RulesFile = v
+		return nil
+	}},
+	{"DUPLICATE_POLICY", "duplicate-policy", "warn or reject near-duplicate jobs", func(c *Config, v string) error {
+		c.Moderation.DuplicatePolicy = v
+		return nil
+	}},
+	{"RATE_LIMIT_STORE", "rate-limit-store", "where rate limits are counted: memory or redis", func(c *Config, v string) error {
+		c.RateLimit.Store = v
+		return nil
+	}},
+	{"REDIS_URL", "redis-url", "Redis URL for the redis rate limit store", func(c *Config, v string) error {
+		c.RateLimit.RedisURL = v
+		return nil
+	}},
+	{"LOG_LEVEL", "log-level", "least severe level logged: debug, info, warn or error", func(c *Config, v string) error {
+		c.Log.Level = v
+		return nil
+	}},
+	{"LOG_FORMAT", "log-format", "log format: json or text", func(c *Config, v string) error {
+		c.Log.Format = v
+		return nil
+	}},
+	{"TRACING_EXPORTER", "tracing-exporter", "where traces are sent: none, stdout or otlp", func(c *Config, v string) error {
+		c.Tracing.Exporter = v
+		return nil
+	}},
+	{"OTLP_ENDPOINT", "otlp-endpoint", "OTLP/HTTP collector URL for the otlp trace exporter", func(c *Config, v string) error {
+		c.Tracing.Endpoint = v
+		return nil
+	}},
+}
+
+// Load builds the config from, in increasing order of precedence: the
+// defaults, the config file, environment variables and command-line flags.
+//
+// The config file is named by the -config flag or the CONFIG_FILE
+// variable, and must exist if named; otherwise config.json is read if
+// present. args are the command-line arguments without the program name.
+// The result is validated before it is returned.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	flags := flag.NewFlagSet("job-board", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	configFile := flags.String("config", "", "path of the JSON config file")
+	values := make([]*string, len(settings))
+	for i, s := range settings {
+		values[i] = flags.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	config := Default()
+
+	path, required := *configFile, true
+	if path == "" {
+		path = getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	if err := config.readFile(path, required); err != nil {
+		return Config{}, err
+	}
+
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" {
+			if err := s.apply(&config, value); err != nil {
+				return Config{}, fmt.Errorf("config: invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	// Only flags given on the command line override, so an unset flag
+	// doesn't reset a value from the file or environment
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		for i, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if applyErr := s.apply(&config, *values[i]); applyErr != nil {
+					err = fmt.Errorf("config: invalid -%s: %w", s.flag, applyErr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("config: %w", err)
+	}
+	return config, nil
+}
+
+// readFile merges the JSON file at path over the config. Settings left
+// out of the file keep their current values.
+func (c *Config) readFile(path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("config: could not read %s: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("config: could not parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
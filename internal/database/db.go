@@ -1,41 +1,90 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
 
 	_ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
 
-func InitDB() {
+// queryTimeout bounds each call into the data layer
+var queryTimeout = 5 * time.Second
+
+// exportTimeout bounds streaming a whole table out of the data layer
+var exportTimeout = 5 * time.Minute
+
+// SetQueryTimeout changes how long a call into the data layer may take
+func SetQueryTimeout(timeout time.Duration) {
+	queryTimeout = timeout
+}
+
+// SetExportTimeout changes how long streaming an export may take
+func SetExportTimeout(timeout time.Duration) {
+	exportTimeout = timeout
+}
+
+// WithTimeout returns ctx limited to the query timeout. The queries run
+// with it are interrupted when it expires or ctx is cancelled, e.g.
+// because the client went away, and the cancel function must be called
+// once they are done.
+func WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, queryTimeout)
+}
+
+// WithExportTimeout is WithTimeout for the longer export timeout, for
+// queries whose rows are streamed to a client as they are read
+func WithExportTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, exportTimeout)
+}
+
+// InitDB opens and migrates the database described by cfg
+func InitDB(cfg config.Database) {
 	var err error
-	DB, err = sql.Open("sqlite", "job.db")
+	DB, err = Open(cfg.Path)
 	if err != nil {
 		panic("could not connect to database")
 	}
 
-	DB.SetMaxOpenConns(10)
-	DB.SetMaxIdleConns(5)
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+	SetQueryTimeout(cfg.QueryTimeout.Duration())
+	SetExportTimeout(cfg.ExportTimeout.Duration())
 
-	createTable()
+	if err := Migrate(); err != nil {
+		panic("could not migrate database: " + err.Error())
+	}
 }
 
-func createTable() {
-	createJobsTable := `
-	CREATE TABLE IF NOT EXISTS jobs (
-		id TEXT PRIMARY KEY,
-		title TEXT NOT NULL,
-    description TEXT NOT NULL,
-		location TEXT NOT NULL,
-		salary FLOAT NOT NULL,
-		duties TEXT NOT NULL,
-		url TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+// Open opens the SQLite database at path with every query traced. Only
+// queries made on behalf of a traced request get spans, so the polling
+// of background workers doesn't flood the traces.
+func Open(path string) (*sql.DB, error) {
+	return otelsql.Open("sqlite", path,
+		otelsql.WithAttributes(semconv.DBSystemNameSQLite),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
 	)
-	`
-	_, err := DB.Exec(createJobsTable)
-	if err != nil {
-		panic("could not create jobs table" + err.Error())
+}
+
+// CloseDB closes the database once every query has finished
+func CloseDB() error {
+	if DB == nil {
+		return nil
 	}
+	return DB.Close()
 }
@@ -0,0 +1,321 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// migration is a numbered change to the database schema
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// migrations are applied in order and each one only once. Never edit a
+// migration that has shipped; add a new one instead.
+//
+// The first migrations use IF NOT EXISTS because they predate migration
+// tracking and may already have been applied to existing databases.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create jobs table",
+		statements: []string{`
+			CREATE TABLE IF NOT EXISTS jobs (
+				id TEXT PRIMARY KEY,
+				title TEXT NOT NULL,
+				description TEXT NOT NULL,
+				location TEXT NOT NULL,
+				salary FLOAT NOT NULL,
+				duties TEXT NOT NULL,
+				url TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+	},
+	{
+		version:     2,
+		description: "create webhook tables",
+		statements: []string{
+			// Webhook subscriptions
+			`CREATE TABLE IF NOT EXISTS webhooks (
+				id TEXT PRIMARY KEY,
+				url TEXT NOT NULL,
+				secret TEXT NOT NULL,
+				events TEXT NOT NULL,
+				active BOOLEAN NOT NULL DEFAULT 1,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+			// Job lifecycle events waiting to be fanned out to subscribers.
+			// Rows are written in the same transaction as the job change.
+			`CREATE TABLE IF NOT EXISTS webhook_outbox (
+				id TEXT PRIMARY KEY,
+				event TEXT NOT NULL,
+				job_id TEXT NOT NULL,
+				payload TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				processed_at TIMESTAMP
+			)`,
+			// One row per attempt to deliver an event to a subscriber
+			`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+				id TEXT PRIMARY KEY,
+				webhook_id TEXT NOT NULL,
+				event_id TEXT NOT NULL,
+				event TEXT NOT NULL,
+				payload TEXT NOT NULL,
+				status TEXT NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 0,
+				response_status INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT NOT NULL DEFAULT '',
+				next_attempt_at TIMESTAMP NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			"CREATE INDEX IF NOT EXISTS idx_webhook_outbox_pending ON webhook_outbox(processed_at)",
+			"CREATE INDEX IF NOT EXISTS idx_webhook_outbox_job ON webhook_outbox(job_id, event)",
+			"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at)",
+			"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at)",
+		},
+	},
+	{
+		version:     3,
+		description: "create audit log",
+		statements: []string{
+			// Append-only log of every change made to a job
+			`CREATE TABLE IF NOT EXISTS job_events (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_id TEXT NOT NULL,
+				actor TEXT NOT NULL,
+				action TEXT NOT NULL,
+				changes TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			// Entries can never be changed or removed once written
+			`CREATE TRIGGER IF NOT EXISTS job_events_no_update
+			BEFORE UPDATE ON job_events
+			BEGIN
+				SELECT RAISE(ABORT, 'job_events is append-only');
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS job_events_no_delete
+			BEFORE DELETE ON job_events
+			BEGIN
+				SELECT RAISE(ABORT, 'job_events is append-only');
+			END`,
+			"CREATE INDEX IF NOT EXISTS idx_job_events_job ON job_events(job_id)",
+			"CREATE INDEX IF NOT EXISTS idx_job_events_actor ON job_events(actor, created_at)",
+			"CREATE INDEX IF NOT EXISTS idx_job_events_created ON job_events(created_at)",
+		},
+	},
+	{
+		version:     4,
+		description: "create job revisions",
+		statements: []string{
+			// Full snapshots of a job taken before each edit
+			`CREATE TABLE IF NOT EXISTS job_revisions (
+				job_id TEXT NOT NULL,
+				revision INTEGER NOT NULL,
+				actor TEXT NOT NULL,
+				snapshot TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (job_id, revision)
+			)`,
+		},
+	},
+	{
+		version:     5,
+		description: "add publishing workflow to jobs",
+		statements: []string{
+			// Jobs that existed before the workflow were already public
+			"ALTER TABLE jobs ADD COLUMN status TEXT NOT NULL DEFAULT 'published'",
+			"ALTER TABLE jobs ADD COLUMN owner_id TEXT NOT NULL DEFAULT ''",
+			"ALTER TABLE jobs ADD COLUMN publish_at TIMESTAMP",
+			"ALTER TABLE jobs ADD COLUMN review_comment TEXT NOT NULL DEFAULT ''",
+			"CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status, publish_at)",
+		},
+	},
+	{
+		version:     6,
+		description: "store rendered HTML of job descriptions",
+		statements: []string{
+			// NULL until the job is next saved, existing jobs are rendered on read
+			"ALTER TABLE jobs ADD COLUMN description_html TEXT",
+			"ALTER TABLE jobs ADD COLUMN duties_html TEXT",
+		},
+	},
+	{
+		version:     7,
+		description: "fingerprint jobs to find duplicates",
+		statements: []string{
+			// SimHash of the job content, NULL for jobs saved before this
+			"ALTER TABLE jobs ADD COLUMN fingerprint INTEGER",
+			"CREATE INDEX IF NOT EXISTS idx_jobs_owner ON jobs(owner_id, created_at)",
+		},
+	},
+	{
+		version:     8,
+		description: "store spam scores of jobs",
+		statements: []string{
+			"ALTER TABLE jobs ADD COLUMN spam_score REAL NOT NULL DEFAULT 0",
+			"ALTER TABLE jobs ADD COLUMN spam_reasons TEXT NOT NULL DEFAULT '[]'",
+		},
+	},
+	{
+		version:     9,
+		description: "add reports and moderation",
+		statements: []string{
+			"ALTER TABLE jobs ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT 0",
+			// Reports filed against jobs by the people reading them
+			`CREATE TABLE job_reports (
+				id TEXT PRIMARY KEY,
+				job_id TEXT NOT NULL,
+				reporter TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				details TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP NOT NULL,
+				resolved_at TIMESTAMP
+			)`,
+			"CREATE INDEX idx_job_reports_job ON job_reports(job_id, resolved_at)",
+			// Employers who may no longer post jobs
+			`CREATE TABLE banned_employers (
+				owner_id TEXT PRIMARY KEY,
+				banned_by TEXT NOT NULL,
+				reason TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP NOT NULL
+			)`,
+			// Every decision a moderator has taken
+			`CREATE TABLE moderation_decisions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				job_id TEXT NOT NULL,
+				owner_id TEXT NOT NULL,
+				moderator TEXT NOT NULL,
+				action TEXT NOT NULL,
+				comment TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP NOT NULL
+			)`,
+			"CREATE INDEX idx_moderation_decisions_job ON moderation_decisions(job_id)",
+		},
+	},
+	{
+		version:     10,
+		description: "stop treating anonymous as an owner",
+		statements: []string{
+			// Jobs posted without a user were owned by "anonymous", which
+			// let every anonymous caller manage them. They are left to staff.
+			"UPDATE jobs SET owner_id = '' WHERE owner_id = 'anonymous'",
+		},
+	},
+	{
+		version:     11,
+		description: "record when jobs were published",
+		statements: []string{
+			// Jobs are open for a while after they are published rather
+			// than created. Until now the two were taken to be the same.
+			"ALTER TABLE jobs ADD COLUMN published_at TIMESTAMP",
+			"UPDATE jobs SET published_at = created_at WHERE status IN ('published', 'closed')",
+		},
+	},
+	{
+		version:     12,
+		description: "record when jobs were last edited",
+		statements: []string{
+			// Jobs edited before this are taken to have been edited when
+			// their last revision was saved
+			"ALTER TABLE jobs ADD COLUMN updated_at TIMESTAMP",
+			"UPDATE jobs SET updated_at = (SELECT MAX(created_at) FROM job_revisions WHERE job_revisions.job_id = jobs.id)",
+		},
+	},
+}
+
+// Migrate applies every migration the database has not seen yet.
+// Each migration runs in its own transaction.
+func Migrate() error {
+	_, err := DB.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			description TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := apply(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PendingMigrations returns the versions of the migrations that have not
+// been applied to the database yet, in the order they would be applied
+func PendingMigrations(ctx context.Context) ([]int, error) {
+	applied, err := appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []int
+	for _, m := range migrations {
+		if !applied[m.version] {
+			pending = append(pending, m.version)
+		}
+	}
+	return pending, nil
+}
+
+// appliedMigrations returns the versions recorded in schema_migrations
+func appliedMigrations(ctx context.Context) (map[int]bool, error) {
+	rows, err := DB.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs a single migration and records it
+func apply(m migration) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO schema_migrations(version, description, applied_at) VALUES(?, ?, ?)",
+		m.version, m.description, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,47 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+// NewCSVWriter returns a Writer that produces a CSV file with a header row
+func NewCSVWriter(w io.Writer) (Writer, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer}, nil
+}
+
+func (c *csvWriter) WriteJob(job models.Job) error {
+	cells := record(job)
+	for i, column := range Columns {
+		if column != "salary" {
+			cells[i] = escapeFormula(cells[i])
+		}
+	}
+	return c.writer.Write(cells)
+}
+
+// escapeFormula quotes a cell that a spreadsheet would otherwise run as a
+// formula, so a posting can't inject one into the file of whoever opens
+// the export
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// DutiesSeparator joins a job's duties into a single cell for tabular formats.
+// The CSV importer splits the duties column on ";" so exports round-trip.
+const DutiesSeparator = "; "
+
+// Columns are the header names used by the tabular formats
+var Columns = []string{"id", "title", "description", "location", "salary", "duties", "url", "created_at", "expired"}
+
+// Writer writes jobs to an export file one at a time
+type Writer interface {
+	// WriteJob appends a single job to the export
+	WriteJob(job models.Job) error
+	// Close flushes any buffered data and finishes the file
+	Close() error
+}
+
+// Format describes a supported export file format
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	NewWriter   func(w io.Writer) (Writer, error)
+}
+
+// Formats lists the supported export formats by name
+var Formats = map[string]Format{
+	"csv": {
+		Name:        "csv",
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   "csv",
+		NewWriter:   NewCSVWriter,
+	},
+	"ndjson": {
+		Name:        "ndjson",
+		ContentType: "application/x-ndjson",
+		Extension:   "ndjson",
+		NewWriter:   NewNDJSONWriter,
+	},
+	"xlsx": {
+		Name:        "xlsx",
+		ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		Extension:   "xlsx",
+		NewWriter:   NewXLSXWriter,
+	},
+}
+
+// Lookup returns the export format with the given name
+func Lookup(name string) (Format, error) {
+	format, ok := Formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("unsupported export format %q", name)
+	}
+	return format, nil
+}
+
+// record flattens a job into string cells in the order of Columns
+func record(job models.Job) []string {
+	return []string{
+		job.ID,
+		job.Title,
+		job.Description,
+		job.Location,
+		strconv.FormatFloat(job.Salary, 'f', -1, 64),
+		strings.Join(job.Duties, DutiesSeparator),
+		job.Url,
+		job.CreatedAt,
+		strconv.FormatBool(job.Expired),
+	}
+}
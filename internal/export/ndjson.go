@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+// NewNDJSONWriter returns a Writer that produces one JSON encoded job per line
+func NewNDJSONWriter(w io.Writer) (Writer, error) {
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+}
+
+func (n *ndjsonWriter) WriteJob(job models.Job) error {
+	return n.encoder.Encode(job)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
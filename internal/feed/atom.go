@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published,omitempty"`
+	Updated   string   `xml:"updated"`
+	Summary   string   `xml:"summary"`
+	Author    atomName `xml:"author"`
+}
+
+type atomName struct {
+	Name string `xml:"name"`
+}
+
+// Atom renders the jobs as an Atom 1.0 document
+func Atom(channel Channel, jobs []models.Job, link LinkFunc) ([]byte, error) {
+	updated := LastModified(jobs)
+	if updated.IsZero() {
+		updated = time.Unix(0, 0).UTC()
+	}
+
+	doc := atomFeed{
+		ID:      channel.Self,
+		Title:   channel.Title,
+		Updated: updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: channel.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: channel.Link, Rel: "alternate", Type: "text/html"},
+		},
+	}
+
+	for _, job := range jobs {
+		entry := atomEntry{
+			// Job IDs are UUIDs, which makes a stable and unique entry ID
+			ID:      "urn:uuid:" + job.ID,
+			Title:   job.Title,
+			Link:    atomLink{Href: link(job.ID), Rel: "alternate", Type: "text/html"},
+			Summary: summary(job),
+			Author:  atomName{Name: channel.Title},
+		}
+		published, modified := publishedAt(job), job.ModifiedTime().UTC()
+		if published.IsZero() {
+			published, modified = updated, updated
+		}
+		entry.Published = published.Format(time.RFC3339)
+		entry.Updated = modified.Format(time.RFC3339)
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshal(doc)
+}
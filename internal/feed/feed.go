@@ -0,0 +1,69 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/markup"
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// Channel describes the feed as a whole
+type Channel struct {
+	Title       string
+	Description string
+	Link        string // the page the feed is about
+	Self        string // the URL of the feed itself
+}
+
+// LinkFunc returns the job details page for a job ID
+type LinkFunc func(jobID string) string
+
+// LastModified returns the most recent time one of the jobs was published
+// or edited, or the zero time if there are none
+func LastModified(jobs []models.Job) time.Time {
+	var latest time.Time
+	for _, job := range jobs {
+		if modified := job.ModifiedTime().UTC(); modified.After(latest) {
+			latest = modified
+		}
+	}
+	return latest
+}
+
+// publishedAt returns when a job was published, or the zero time if that
+// is unknown
+func publishedAt(job models.Job) time.Time {
+	return job.PublishedTime().UTC()
+}
+
+// summary is the plain text shown for a job in feed readers, stripped from
+// the sanitized HTML of its description and duties
+func summary(job models.Job) string {
+	text := markup.PlainText(job.DescriptionHTML)
+	if job.Location != "" {
+		text += "\n\nLocation: " + job.Location
+	}
+	if job.Salary > 0 {
+		text += fmt.Sprintf("\nSalary: %.0f", job.Salary)
+	}
+	if len(job.DutiesHTML) > 0 {
+		duties := make([]string, len(job.DutiesHTML))
+		for i, duty := range job.DutiesHTML {
+			duties[i] = markup.PlainText(duty)
+		}
+		text += "\nDuties: " + strings.Join(duties, ", ")
+	}
+	return text
+}
+
+// marshal encodes a feed document with the XML declaration
+func marshal(doc interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,73 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS renders the jobs as an RSS 2.0 document
+func RSS(channel Channel, jobs []models.Job, link LinkFunc) ([]byte, error) {
+	doc := rss{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       channel.Title,
+			Link:        channel.Link,
+			Description: channel.Description,
+			AtomLink:    atomLink{Href: channel.Self, Rel: "self", Type: "application/rss+xml"},
+			Items:       []rssItem{},
+		},
+	}
+
+	if updated := LastModified(jobs); !updated.IsZero() {
+		doc.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for _, job := range jobs {
+		item := rssItem{
+			Title:       job.Title,
+			Link:        link(job.ID),
+			Description: summary(job),
+			// Job IDs never change, so feed readers can rely on them
+			// to spot items they have already seen
+			GUID: rssGUID{IsPermaLink: false, Value: job.ID},
+		}
+		if published := publishedAt(job); !published.IsZero() {
+			item.PubDate = published.Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	return marshal(doc)
+}
@@ -0,0 +1,77 @@
+package fingerprint
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the number of consecutive words hashed together.
+// Small shingles keep fingerprints stable when a word or two is edited.
+const shingleSize = 2
+
+// SimHash fingerprints a job from its normalized title, location and
+// description. Similar postings get fingerprints that differ in only a
+// few bits, see Distance. Short postings are noisier than long ones, a
+// single edited word can flip several bits.
+func SimHash(title, location, description string) uint64 {
+	var weights [64]int
+
+	add := func(feature string, weight int) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit] += weight
+			} else {
+				weights[bit] -= weight
+			}
+		}
+	}
+
+	// The title says what the job is, so it counts for more than any
+	// single phrase of the description
+	add("title:"+strings.Join(words(title), " "), 3)
+	add("location:"+strings.Join(words(location), " "), 1)
+	for _, shingle := range shingles(words(description)) {
+		add(shingle, 1)
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+
+	return fingerprint
+}
+
+// Distance counts the bits two fingerprints differ in
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// words lowercases text and splits it into words, dropping punctuation
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// shingles returns every run of shingleSize consecutive words
+func shingles(words []string) []string {
+	if len(words) <= shingleSize {
+		return []string{strings.Join(words, " ")}
+	}
+
+	result := make([]string, 0, len(words)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(words); i++ {
+		result = append(result, strings.Join(words[i:i+shingleSize], " "))
+	}
+
+	return result
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Ademayowa/job-board/internal/logging"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Error codes reported under extensions.code
+const (
+	CodeBadUserInput       = "BAD_USER_INPUT"
+	CodeUnauthenticated    = "UNAUTHENTICATED"
+	CodeNotFound           = "NOT_FOUND"
+	CodeForbidden          = "FORBIDDEN"
+	CodeConflict           = "CONFLICT"
+	CodeDepthExceeded      = "QUERY_TOO_DEEP"
+	CodeComplexityExceeded = "QUERY_TOO_COMPLEX"
+	CodeInternal           = "INTERNAL_SERVER_ERROR"
+)
+
+// Error is an error reported to the client, with a code telling what
+// went wrong and any details alongside it
+type Error struct {
+	Code    string
+	Message string
+	Details map[string]interface{}
+}
+
+// newError returns an error reporting message with code
+func newError(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// With returns the error with a detail added under key
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions returns the code and details, which are added to the error
+// in the response
+func (e *Error) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.Code}
+	for key, value := range e.Details {
+		extensions[key] = value
+	}
+	return extensions
+}
+
+// format returns the error as reported for a request that isn't run
+func (e *Error) format() gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{
+		Message:    e.Message,
+		Locations:  []location.SourceLocation{},
+		Extensions: e.Extensions(),
+	}
+}
+
+// internalError logs err with the request's context and returns an error
+// carrying only message, so internals don't leak to clients
+func internalError(ctx context.Context, message string, err error) error {
+	trace.SpanFromContext(ctx).RecordError(err)
+	logging.FromContext(ctx).Error(message, "error", err, "route", "/graphql")
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		message += ": the database took too long to respond"
+	}
+	return newError(CodeInternal, message)
+}
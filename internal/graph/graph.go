@@ -0,0 +1,116 @@
+// Package graph serves the job board over GraphQL. Jobs are exposed with
+// the companies that posted them, a company being the employer a job is
+// owned by.
+package graph
+
+import (
+	"context"
+
+	"github.com/Ademayowa/job-board/internal/models"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is a GraphQL request as clients post it
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Limits bound how much work a single query can ask for
+type Limits struct {
+	// MaxDepth is how deeply fields can be nested
+	MaxDepth int
+	// MaxComplexity is the most fields a query can resolve, counting the
+	// fields of a list once for every item it may return
+	MaxComplexity int
+}
+
+// Viewer is the user a request is made by. ID is empty for anonymous
+// requests.
+type Viewer struct {
+	ID   string
+	Role string
+}
+
+// viewerKey is the context key of the request's viewer
+type viewerKey struct{}
+
+// WithViewer returns ctx carrying the user making the request
+func WithViewer(ctx context.Context, viewer Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, viewer)
+}
+
+// viewerFrom returns the user making the request, which is empty if the
+// request is anonymous
+func viewerFrom(ctx context.Context) Viewer {
+	viewer, _ := ctx.Value(viewerKey{}).(Viewer)
+	return viewer
+}
+
+// requireViewer returns the user making the request, or an error if the
+// request is anonymous
+func requireViewer(ctx context.Context) (Viewer, error) {
+	viewer := viewerFrom(ctx)
+	if viewer.ID == "" {
+		return viewer, newError(CodeUnauthenticated, "authentication required")
+	}
+	return viewer, nil
+}
+
+// Schema executes GraphQL requests against the job board
+type Schema struct {
+	schema graphql.Schema
+	limits Limits
+	// duplicates is the policy for jobs that repeat an earlier one
+	duplicates string
+	// prepare is applied to new and edited jobs before they are saved
+	prepare func(job *models.Job)
+}
+
+// New builds the schema. Near-duplicate jobs are handled by the duplicates
+// policy, and prepare is applied to every job created or edited through it
+// before it is saved, e.g. to score it for spam.
+func New(limits Limits, duplicates string, prepare func(job *models.Job)) *Schema {
+	s := &Schema{limits: limits, duplicates: duplicates, prepare: prepare}
+
+	schema, err := graphql.NewSchema(s.config())
+	if err != nil {
+		// The schema is fixed, so this is a bug rather than bad input
+		panic("graph: invalid schema: " + err.Error())
+	}
+	s.schema = schema
+
+	return s
+}
+
+// Execute runs a request. Queries that go beyond the limits are rejected
+// before anything is resolved.
+func (s *Schema) Execute(ctx context.Context, request Request) *graphql.Result {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	if result := graphql.ValidateDocument(&s.schema, document, nil); !result.IsValid {
+		return &graphql.Result{Errors: result.Errors}
+	}
+
+	if err := s.checkLimits(document, request.OperationName, request.Variables); err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{err.format()}}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       withLoaders(ctx),
+	})
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// cost is what resolving a selection set asks for
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// checkLimits measures the operation a request runs and rejects it if it
+// nests fields too deeply or asks for too many. Introspection is exempt,
+// so tools can always load the schema.
+func (s *Schema) checkLimits(document *ast.Document, operationName string, variables map[string]interface{}) *Error {
+	var operation *ast.OperationDefinition
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		}
+	}
+	// Leave reporting a missing operation to the executor
+	if operation == nil {
+		return nil
+	}
+
+	root := s.schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = s.schema.MutationType()
+	}
+
+	// Variables left out of the request take their default
+	defaults := map[string]ast.Value{}
+	for _, definition := range operation.VariableDefinitions {
+		if definition.DefaultValue != nil {
+			defaults[definition.Variable.Name.Value] = definition.DefaultValue
+		}
+	}
+
+	measure := measurer{schema: &s.schema, fragments: fragments, variables: variables, defaults: defaults}
+	total := measure.selections(operation.SelectionSet, root)
+
+	if total.depth > s.limits.MaxDepth {
+		return newError(CodeDepthExceeded, fmt.Sprintf("query is nested %d levels deep, more than the limit of %d", total.depth, s.limits.MaxDepth)).
+			With("depth", total.depth).With("max_depth", s.limits.MaxDepth)
+	}
+	if total.complexity > s.limits.MaxComplexity {
+		return newError(CodeComplexityExceeded, fmt.Sprintf("query has a complexity of %d, more than the limit of %d", total.complexity, s.limits.MaxComplexity)).
+			With("complexity", total.complexity).With("max_complexity", s.limits.MaxComplexity)
+	}
+	return nil
+}
+
+// measurer walks the selections of a validated operation
+type measurer struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	defaults  map[string]ast.Value
+}
+
+// selections measures a selection set on parent. Every field costs one,
+// and the fields below a list cost once for each item it may return.
+func (m measurer) selections(set *ast.SelectionSet, parent *graphql.Object) cost {
+	var total cost
+	if set == nil || parent == nil {
+		return total
+	}
+
+	add := func(c cost) {
+		total.depth = max(total.depth, c.depth)
+		total.complexity += c.complexity
+	}
+
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			name := selection.Name.Value
+			if strings.HasPrefix(name, "__") {
+				continue
+			}
+			definition, ok := parent.Fields()[name]
+			if !ok {
+				continue
+			}
+
+			child, _ := graphql.GetNamed(definition.Type).(*graphql.Object)
+			below := m.selections(selection.SelectionSet, child)
+			add(cost{
+				depth:      below.depth + 1,
+				complexity: 1 + m.first(selection, definition)*below.complexity,
+			})
+		case *ast.FragmentSpread:
+			if fragment, ok := m.fragments[selection.Name.Value]; ok {
+				add(m.selections(fragment.SelectionSet, m.object(fragment.TypeCondition, parent)))
+			}
+		case *ast.InlineFragment:
+			add(m.selections(selection.SelectionSet, m.object(selection.TypeCondition, parent)))
+		}
+	}
+	return total
+}
+
+// object returns the object type a fragment applies to
+func (m measurer) object(condition *ast.Named, parent *graphql.Object) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := m.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
+
+// first returns how many items a field asks for with its first argument,
+// or 1 for fields without one. It counts no more than the resolvers
+// return, so a first they will reject doesn't inflate the cost.
+func (m measurer) first(field *ast.Field, definition *graphql.FieldDefinition) int {
+	n := 1
+	for _, argument := range definition.Args {
+		if argument.Name() == "first" {
+			if value, ok := argument.DefaultValue.(int); ok {
+				n = value
+			}
+		}
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		value := argument.Value
+		if variable, ok := value.(*ast.Variable); ok {
+			switch provided := m.variables[variable.Name.Value].(type) {
+			case float64:
+				n = int(provided)
+			case int:
+				n = provided
+			case nil:
+				value = m.defaults[variable.Name.Value]
+			}
+		}
+		if literal, ok := value.(*ast.IntValue); ok {
+			n, _ = strconv.Atoi(literal.Value)
+		}
+	}
+
+	return min(max(n, 1), maxFirst)
+}
@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// loader batches the keys asked for while one level of a query is
+// resolved. Resolvers return the thunk from load instead of a value; the
+// executor calls the thunks only once every sibling field has asked for
+// its key, so the first thunk called fetches them all with one call.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	values  map[K]V
+	errs    map[K]error
+}
+
+// newLoader returns a loader that fetches batches of keys with fetch.
+// Keys fetch leaves out of its result get the zero value.
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, values: map[K]V{}, errs: map[K]error{}}
+}
+
+// load queues key for the next batch and returns a thunk resolving to its value
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, done := l.values[key]; !done {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		return l.get(ctx, key)
+	}
+}
+
+// get returns the value of key, fetching the pending batch first
+func (l *loader[K, V]) get(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) > 0 {
+		keys := unique(l.pending)
+		l.pending = nil
+
+		values, err := l.fetch(ctx, keys)
+		for _, k := range keys {
+			l.values[k] = values[k]
+			if err != nil {
+				l.errs[k] = err
+			}
+		}
+	}
+
+	return l.values[key], l.errs[key]
+}
+
+// unique returns keys without repeats, in their first order
+func unique[K comparable](keys []K) []K {
+	seen := make(map[K]bool, len(keys))
+	var result []K
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// ownerJobsKey asks for a page of an owner's jobs
+type ownerJobsKey struct {
+	owner  string
+	filter models.JobFilter
+}
+
+// relatedJobsKey asks for the jobs related to a job
+type relatedJobsKey struct {
+	job      string
+	location string
+	first    int
+}
+
+// loaders are the loaders of one request. They are never shared between
+// requests, so nothing is cached longer than a request lasts.
+type loaders struct {
+	ownerJobs   *loader[ownerJobsKey, models.JobPage]
+	relatedJobs *loader[relatedJobsKey, []models.Job]
+}
+
+// loadersKey is the context key of the request's loaders
+type loadersKey struct{}
+
+// withLoaders returns ctx carrying a fresh set of loaders
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		ownerJobs:   newLoader(fetchOwnerJobs),
+		relatedJobs: newLoader(fetchRelatedJobs),
+	})
+}
+
+// loadersFrom returns the loaders of the request ctx belongs to
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// fetchOwnerJobs lists the jobs of every owner asked for with one query
+// per distinct filter, which usually means one query
+func fetchOwnerJobs(ctx context.Context, keys []ownerJobsKey) (map[ownerJobsKey]models.JobPage, error) {
+	owners := map[models.JobFilter][]string{}
+	for _, key := range keys {
+		owners[key.filter] = append(owners[key.filter], key.owner)
+	}
+
+	result := make(map[ownerJobsKey]models.JobPage, len(keys))
+	for filter, ids := range owners {
+		pages, err := models.ListJobsByOwner(ctx, ids, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			result[ownerJobsKey{owner: id, filter: filter}] = pages[id]
+		}
+	}
+	return result, nil
+}
+
+// fetchRelatedJobs finds the newest other jobs in the location of each
+// job asked for, with one query per distinct number of jobs
+func fetchRelatedJobs(ctx context.Context, keys []relatedJobsKey) (map[relatedJobsKey][]models.Job, error) {
+	locations := map[int][]string{}
+	for _, key := range keys {
+		locations[key.first] = append(locations[key.first], key.location)
+	}
+
+	result := make(map[relatedJobsKey][]models.Job, len(keys))
+	for first, names := range locations {
+		// One more than asked for, in case the job itself is among them
+		pages, err := models.ListJobsByLocation(ctx, unique(names), models.JobFilter{Sort: models.JobSortRecent, First: first + 1})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if key.first != first {
+				continue
+			}
+			related := []models.Job{}
+			for _, job := range pages[key.location].Jobs {
+				if job.ID != key.job && len(related) < first {
+					related = append(related, job)
+				}
+			}
+			result[key] = related
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,556 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/Ademayowa/job-board/internal/metrics"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/validation"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Page sizes for lists of jobs
+const (
+	defaultFirst = 10
+	maxFirst     = 100
+)
+
+// company is the employer that posted a job
+type company struct {
+	ID string
+}
+
+// connection is a page of jobs as a GraphQL connection
+type connection struct {
+	page models.JobPage
+}
+
+// edge is a job in a connection along with its cursor
+type edge struct {
+	cursor string
+	job    models.Job
+}
+
+// config builds the types of the schema. They are built in a function
+// rather than as package variables because they refer to each other.
+func (s *Schema) config() graphql.SchemaConfig {
+	var jobType, companyType, connectionType *graphql.Object
+
+	jobSort := graphql.NewEnum(graphql.EnumConfig{
+		Name:        "JobSort",
+		Description: "The order jobs are listed in",
+		Values: graphql.EnumValueConfigMap{
+			"RECENT":         {Value: models.JobSortRecent, Description: "Newest first"},
+			"HIGHEST_SALARY": {Value: models.JobSortSalary, Description: "Best paid first"},
+		},
+	})
+
+	// Arguments of every list of jobs
+	listArgs := graphql.FieldConfigArgument{
+		"query": {Type: graphql.String, Description: "Only list jobs whose title contains it"},
+		"sort":  {Type: jobSort, DefaultValue: models.JobSortRecent},
+		"first": {Type: graphql.Int, DefaultValue: defaultFirst, Description: "How many jobs to list, at most 100"},
+		"after": {Type: graphql.String, Description: "The cursor of the job to list from"},
+	}
+
+	jobType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Job",
+		Description: "A job posted on the board",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":              jobField(graphql.NewNonNull(graphql.ID), func(job models.Job) interface{} { return job.ID }),
+				"title":           jobField(graphql.NewNonNull(graphql.String), func(job models.Job) interface{} { return job.Title }),
+				"description":     jobField(graphql.NewNonNull(graphql.String), func(job models.Job) interface{} { return job.Description }),
+				"descriptionHtml": jobField(graphql.NewNonNull(graphql.String), func(job models.Job) interface{} { return job.DescriptionHTML }),
+				"location":        jobField(graphql.NewNonNull(graphql.String), func(job models.Job) interface{} { return job.Location }),
+				"salary":          jobField(graphql.NewNonNull(graphql.Float), func(job models.Job) interface{} { return job.Salary }),
+				"duties":          jobField(stringList, func(job models.Job) interface{} { return nonNil(job.Duties) }),
+				"dutiesHtml":      jobField(stringList, func(job models.Job) interface{} { return nonNil(job.DutiesHTML) }),
+				"url":             jobField(graphql.String, func(job models.Job) interface{} { return optional(job.Url) }),
+				"status":          jobField(graphql.NewNonNull(graphql.String), func(job models.Job) interface{} { return job.Status }),
+				"createdAt":       jobField(graphql.NewNonNull(graphql.String), func(job models.Job) interface{} { return job.CreatedAt }),
+				"publishedAt": jobField(graphql.String, func(job models.Job) interface{} {
+					if publishedAt := job.PublishedTime(); !publishedAt.IsZero() {
+						return publishedAt.Format(models.DateFormat)
+					}
+					return nil
+				}),
+				"expiresAt": jobField(graphql.String, func(job models.Job) interface{} {
+					if expiresAt := job.ExpiresAt(); !expiresAt.IsZero() {
+						return expiresAt.Format(models.DateFormat)
+					}
+					return nil
+				}),
+				"expired":            jobField(graphql.NewNonNull(graphql.Boolean), func(job models.Job) interface{} { return job.Expired }),
+				"publishAt":          jobField(graphql.String, func(job models.Job) interface{} { return optional(job.PublishAt) }),
+				"hidden":             jobField(graphql.NewNonNull(graphql.Boolean), func(job models.Job) interface{} { return job.Hidden }),
+				"possibleDuplicates": jobField(graphql.NewList(graphql.NewNonNull(graphql.ID)), func(job models.Job) interface{} { return job.PossibleDuplicates }),
+				"company": &graphql.Field{
+					Type:        companyType,
+					Description: "The employer that posted the job",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						job := p.Source.(models.Job)
+						if job.OwnerID == "" {
+							return nil, nil
+						}
+						return company{ID: job.OwnerID}, nil
+					},
+				},
+				"relatedJobs": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(jobType))),
+					Description: "The newest other jobs in the same location",
+					Args: graphql.FieldConfigArgument{
+						"first": {Type: graphql.Int, DefaultValue: 3},
+					},
+					Resolve: resolveRelatedJobs,
+				},
+			}
+		}),
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "JobEdge",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"cursor": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(edge).cursor, nil
+					},
+				},
+				"node": &graphql.Field{
+					Type: graphql.NewNonNull(jobType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(edge).job, nil
+					},
+				},
+			}
+		}),
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.JobPage).HasNextPage, nil
+				},
+			},
+			"endCursor": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The cursor to list the next page from",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					cursors := p.Source.(models.JobPage).Cursors
+					if len(cursors) == 0 {
+						return nil, nil
+					}
+					return cursors[len(cursors)-1], nil
+				},
+			},
+		},
+	})
+
+	connectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "JobConnection",
+		Description: "A page of jobs",
+		Fields: graphql.Fields{
+			"edges": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					page := p.Source.(connection).page
+					edges := make([]edge, len(page.Jobs))
+					for i, job := range page.Jobs {
+						edges[i] = edge{cursor: page.Cursors[i], job: job}
+					}
+					return edges, nil
+				},
+			},
+			"pageInfo": &graphql.Field{
+				Type: graphql.NewNonNull(pageInfoType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(connection).page, nil
+				},
+			},
+			"totalCount": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "How many jobs there are across every page",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(connection).page.Total, nil
+				},
+			},
+		},
+	})
+
+	companyType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Company",
+		Description: "An employer posting jobs",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(company).ID, nil
+				},
+			},
+			"jobs": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "The public jobs of the company",
+				Args:        listArgs,
+				Resolve:     resolveCompanyJobs,
+			},
+		},
+	})
+
+	jobInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "JobInput",
+		Description: "The fields of a job an employer can edit",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       {Type: graphql.NewNonNull(graphql.String)},
+			"description": {Type: graphql.NewNonNull(graphql.String), Description: "Markdown"},
+			"location":    {Type: graphql.NewNonNull(graphql.String)},
+			"salary":      {Type: graphql.NewNonNull(graphql.Float)},
+			"duties":      {Type: stringList, Description: "Markdown, one line each"},
+			"url":         {Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"job": &graphql.Field{
+				Type:        jobType,
+				Description: "The job with the given ID, if the viewer can see it",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolveJob,
+			},
+			"jobs": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "The public jobs",
+				Args:        listArgs,
+				Resolve:     resolveJobs,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createJob": &graphql.Field{
+				Type:        graphql.NewNonNull(jobType),
+				Description: "Post a job as a draft owned by the viewer",
+				Args: graphql.FieldConfigArgument{
+					"input": {Type: graphql.NewNonNull(jobInput)},
+				},
+				Resolve: s.resolveCreateJob,
+			},
+			"updateJob": &graphql.Field{
+				Type:        graphql.NewNonNull(jobType),
+				Description: "Overwrite the editable fields of a job owned by the viewer. A published or scheduled job goes back to review, or is held if the edit looks like spam.",
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: graphql.NewNonNull(graphql.ID)},
+					"input": {Type: graphql.NewNonNull(jobInput)},
+				},
+				Resolve: s.resolveUpdateJob,
+			},
+			"deleteJob": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Delete a job owned by the viewer, returning its ID",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolveDeleteJob,
+			},
+		},
+	})
+
+	return graphql.SchemaConfig{Query: query, Mutation: mutation}
+}
+
+// stringList is a list of strings that is never null
+var stringList = graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))
+
+// jobField returns a field of a job read by get
+func jobField(t graphql.Output, get func(job models.Job) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(models.Job)), nil
+		},
+	}
+}
+
+// nonNil returns an empty list instead of nil
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// optional returns nil for an empty string
+func optional(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// Fetch a single job
+func resolveJob(p graphql.ResolveParams) (interface{}, error) {
+	job, err := visibleJob(p.Context, p.Args["id"].(string))
+	if errors.Is(err, errNotVisible) {
+		return nil, nil
+	}
+	return job, err
+}
+
+// errNotVisible is returned for jobs that don't exist or the viewer can't see
+var errNotVisible = newError(CodeNotFound, "job not found")
+
+// visibleJob fetches a job the viewer can see
+func visibleJob(ctx context.Context, id string) (models.Job, error) {
+	job, err := models.GetJobByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, errNotVisible
+	}
+	if err != nil {
+		return job, internalError(ctx, "could not fetch job", err)
+	}
+
+	viewer := viewerFrom(ctx)
+	if !models.CanView(job, viewer.ID, viewer.Role) {
+		return job, errNotVisible
+	}
+	return job, nil
+}
+
+// editableJob fetches a job the viewer may change, as its owner or an admin
+func editableJob(ctx context.Context, id, action string) (models.Job, error) {
+	job, err := visibleJob(ctx, id)
+	if err != nil {
+		return job, err
+	}
+
+	viewer := viewerFrom(ctx)
+	if !models.CanEdit(job, viewer.ID, viewer.Role) {
+		return job, newError(CodeForbidden, "only the job's owner and admins can "+action+" it")
+	}
+	return job, nil
+}
+
+// jobFilter reads the arguments of a list of jobs
+func jobFilter(p graphql.ResolveParams) (models.JobFilter, error) {
+	filter := models.JobFilter{
+		Sort:  p.Args["sort"].(string),
+		First: p.Args["first"].(int),
+	}
+	if query, ok := p.Args["query"].(string); ok {
+		filter.Title = query
+	}
+	if after, ok := p.Args["after"].(string); ok {
+		filter.After = after
+	}
+
+	if filter.First < 1 || filter.First > maxFirst {
+		return filter, newError(CodeBadUserInput, "first must be between 1 and 100").With("argument", "first")
+	}
+	return filter, nil
+}
+
+// listError reports an error listing jobs
+func listError(ctx context.Context, err error) error {
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return newError(CodeBadUserInput, "after is not a valid cursor").With("argument", "after")
+	}
+	return internalError(ctx, "could not fetch jobs", err)
+}
+
+// Fetch a page of the public jobs
+func resolveJobs(p graphql.ResolveParams) (interface{}, error) {
+	filter, err := jobFilter(p)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := models.ListJobs(p.Context, filter)
+	if err != nil {
+		return nil, listError(p.Context, err)
+	}
+
+	// Track searches that find nothing, they show what people miss
+	if strings.TrimSpace(filter.Title) != "" {
+		metrics.Searches.Inc()
+		if page.Total == 0 {
+			metrics.SearchesWithoutResults.Inc()
+		}
+	}
+
+	return connection{page: page}, nil
+}
+
+// Fetch a page of a company's jobs, along with those of every other
+// company in the same query
+func resolveCompanyJobs(p graphql.ResolveParams) (interface{}, error) {
+	filter, err := jobFilter(p)
+	if err != nil {
+		return nil, err
+	}
+
+	key := ownerJobsKey{owner: p.Source.(company).ID, filter: filter}
+	thunk := loadersFrom(p.Context).ownerJobs.load(p.Context, key)
+
+	return func() (interface{}, error) {
+		page, err := thunk()
+		if err != nil {
+			return nil, listError(p.Context, err)
+		}
+		return connection{page: page.(models.JobPage)}, nil
+	}, nil
+}
+
+// Fetch the jobs related to a job, along with those of every other job
+// in the same query
+func resolveRelatedJobs(p graphql.ResolveParams) (interface{}, error) {
+	first := p.Args["first"].(int)
+	if first < 1 || first > maxFirst {
+		return nil, newError(CodeBadUserInput, "first must be between 1 and 100").With("argument", "first")
+	}
+
+	job := p.Source.(models.Job)
+	thunk := loadersFrom(p.Context).relatedJobs.load(p.Context, relatedJobsKey{job: job.ID, location: job.Location, first: first})
+
+	return func() (interface{}, error) {
+		related, err := thunk()
+		if err != nil {
+			return nil, internalError(p.Context, "could not fetch related jobs", err)
+		}
+		return related, nil
+	}, nil
+}
+
+// jobInputFrom reads and validates the job input of a mutation
+func jobInputFrom(p graphql.ResolveParams) (models.Job, error) {
+	input := p.Args["input"].(map[string]interface{})
+
+	job := models.Job{
+		Title:       input["title"].(string),
+		Description: input["description"].(string),
+		Location:    input["location"].(string),
+		Salary:      input["salary"].(float64),
+	}
+	if url, ok := input["url"].(string); ok {
+		job.Url = url
+	}
+	if duties, ok := input["duties"].([]interface{}); ok {
+		for _, duty := range duties {
+			job.Duties = append(job.Duties, duty.(string))
+		}
+	}
+
+	if err := job.Validate(); err != nil {
+		var validationErrors validation.Errors
+		if errors.As(err, &validationErrors) {
+			return job, newError(CodeBadUserInput, "job data is invalid").With("errors", validationErrors)
+		}
+		return job, newError(CodeBadUserInput, err.Error())
+	}
+	return job, nil
+}
+
+// Create a job
+func (s *Schema) resolveCreateJob(p graphql.ResolveParams) (interface{}, error) {
+	viewer, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := jobInputFrom(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.prepare != nil {
+		s.prepare(&job)
+	}
+
+	err = job.Save(p.Context, viewer.ID, s.duplicates)
+	var duplicateErr *models.DuplicateError
+	if errors.As(err, &duplicateErr) {
+		return nil, newError(CodeConflict, "a similar job was already posted").With("duplicates", duplicateErr.JobIDs)
+	}
+	if errors.Is(err, models.ErrEmployerBanned) {
+		return nil, newError(CodeForbidden, err.Error())
+	}
+	if err != nil {
+		return nil, internalError(p.Context, "could not create job", err)
+	}
+
+	metrics.JobsCreated.WithLabelValues("graphql").Inc()
+
+	return job, nil
+}
+
+// Update a job
+func (s *Schema) resolveUpdateJob(p graphql.ResolveParams) (interface{}, error) {
+	viewer, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	id := p.Args["id"].(string)
+	if _, err := editableJob(p.Context, id, "edit"); err != nil {
+		return nil, err
+	}
+
+	updatedJob, err := jobInputFrom(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Edits are checked for spam like new jobs
+	if s.prepare != nil {
+		s.prepare(&updatedJob)
+	}
+
+	// Convert Duties field to JSON for database storage
+	dutiesJSON, err := json.Marshal(nonNil(updatedJob.Duties))
+	if err != nil {
+		return nil, internalError(p.Context, "error processing duties field", err)
+	}
+
+	err = models.UpdateJobByID(p.Context, id, updatedJob, string(dutiesJSON), viewer.ID, viewer.Role)
+	if errors.Is(err, models.ErrForbidden) {
+		return nil, newError(CodeForbidden, "only the job's owner and admins can edit it")
+	}
+	if err != nil {
+		return nil, internalError(p.Context, "could not update job", err)
+	}
+
+	return visibleJob(p.Context, id)
+}
+
+// Delete a job
+func resolveDeleteJob(p graphql.ResolveParams) (interface{}, error) {
+	viewer, err := requireViewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := editableJob(p.Context, p.Args["id"].(string), "delete")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := job.Delete(p.Context, viewer.ID); err != nil {
+		return nil, internalError(p.Context, "could not delete job", err)
+	}
+
+	return job.ID, nil
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Fetch the change history of a job, which stays available after the job
+// is deleted to those who could see it
+func getJobHistory(context *gin.Context) {
+	jobId := context.Param("id")
+
+	job, err := models.GetJobByID(context.Request.Context(), jobId)
+	if errors.Is(err, sql.ErrNoRows) {
+		job, err = models.GetDeletedJob(context.Request.Context(), jobId)
+	}
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, models.ErrJobNotDeleted) || (err == nil && !canView(context, job)) {
+		response.Error(context, http.StatusNotFound, "no history for job")
+		return
+	}
+	if err != nil {
+		serverError(context, "could not fetch job", err)
+		return
+	}
+
+	events, err := models.GetJobHistory(context.Request.Context(), jobId)
+	if err != nil {
+		serverError(context, "could not fetch job history", err)
+		return
+	}
+
+	if len(events) == 0 {
+		response.Error(context, http.StatusNotFound, "no history for job")
+		return
+	}
+
+	response.OK(context, events)
+}
+
+// Restore a deleted job
+func restoreJob(context *gin.Context) {
+	if !requireUser(context) {
+		return
+	}
+
+	jobId := context.Param("id")
+
+	job, err := models.RestoreJob(context.Request.Context(), jobId, actor(context), role(context))
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Error(context, http.StatusNotFound, "no history for job")
+		return
+	}
+	if errors.Is(err, models.ErrForbidden) {
+		response.Error(context, http.StatusForbidden, "only the job's owner and admins can restore it")
+		return
+	}
+	if errors.Is(err, models.ErrJobNotDeleted) {
+		response.Error(context, http.StatusConflict, "job is not deleted")
+		return
+	}
+	if errors.Is(err, models.ErrRemovedByModerator) {
+		response.Error(context, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, models.ErrEmployerBanned) {
+		response.Error(context, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		serverError(context, "could not restore job", err)
+		return
+	}
+
+	response.Message(context, http.StatusOK, "job restored", "job", presentJob(context, job))
+}
+
+// Fetch the audit log across all jobs, filtered by actor, job, action and time range
+func getAuditLog(context *gin.Context) {
+	if !isModerator(context) {
+		response.Error(context, http.StatusForbidden, "only moderators can view the audit log")
+		return
+	}
+
+	filter := models.AuditFilter{
+		Actor:  context.Query("actor"),
+		JobID:  context.Query("job_id"),
+		Action: context.Query("action"),
+	}
+
+	// Time range bounds are RFC 3339 timestamps
+	for param, bound := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := context.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.Error(context, http.StatusBadRequest, param+" must be an RFC 3339 timestamp")
+			return
+		}
+		*bound = parsed
+	}
+
+	page, err := strconv.Atoi(context.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(context.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	events, total, err := models.GetAuditLog(context.Request.Context(), filter)
+	if err != nil {
+		serverError(context, "could not fetch audit log", err)
+		return
+	}
+
+	response.Page(context, events, response.NewPagination(page, limit, total))
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Ademayowa/job-board/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// docsPage renders the OpenAPI document with Redoc. The bundle is pinned
+// to a release so an upstream change can't alter what the page runs.
+const docsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Job Board API</title>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+  </head>
+  <body>
+    <redoc spec-url="/openapi.json"></redoc>
+    <script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+  </body>
+</html>
+`
+
+// Serve the OpenAPI document of the API
+func getOpenAPI(context *gin.Context) {
+	context.Data(http.StatusOK, "application/json; charset=utf-8", openapi.Spec)
+}
+
+// Serve the interactive API documentation
+func getDocs(context *gin.Context) {
+	context.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// List groups of jobs that look like duplicates of each other, for admins to merge
+func getDuplicateClusters(context *gin.Context) {
+	if role(context) != models.RoleAdmin {
+		response.Error(context, http.StatusForbidden, "only admins can review duplicates")
+		return
+	}
+
+	clusters, err := models.GetDuplicateClusters(context.Request.Context())
+	if err != nil {
+		serverError(context, "could not fetch duplicate jobs", err)
+		return
+	}
+
+	if !response.Enveloped(context) {
+		context.JSON(http.StatusOK, gin.H{"data": presentDuplicateClusters(context, clusters), "total": len(clusters)})
+		return
+	}
+	response.OK(context, presentDuplicateClusters(context, clusters))
+}
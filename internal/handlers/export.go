@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/export"
+	"github.com/Ademayowa/job-board/internal/logging"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Export all jobs matching the filters as a CSV, NDJSON or XLSX download
+func exportJobs(context *gin.Context) {
+	format, err := export.Lookup(context.DefaultQuery("format", "csv"))
+	if err != nil {
+		response.Error(context, http.StatusBadRequest, "format must be csv, ndjson or xlsx")
+		return
+	}
+
+	filterTitle := context.Query("query")
+	filename := "jobs-" + time.Now().Format("2006-01-02") + "." + format.Extension
+
+	context.Header("Content-Type", format.ContentType)
+	context.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	context.Status(http.StatusOK)
+	allowExport(context)
+
+	writer, err := format.NewWriter(context.Writer)
+	if err != nil {
+		abortDownload(context, err)
+	}
+
+	// Rows are written straight from the database cursor to the response.
+	// Once streaming has started the status can no longer change, so a
+	// failure part way through cuts the connection instead.
+	err = models.EachJob(context.Request.Context(), filterTitle, writer.WriteJob)
+	if err != nil {
+		abortDownload(context, err)
+	}
+
+	if err := writer.Close(); err != nil {
+		abortDownload(context, err)
+	}
+}
+
+// abortDownload logs why a download that has started streaming failed and
+// cuts the connection, so the client sees the download fail rather than
+// keeping a truncated file. It doesn't return.
+func abortDownload(context *gin.Context, err error) {
+	context.Error(err)
+	logging.FromContext(context.Request.Context()).Error("download failed",
+		"route", context.FullPath(),
+		"error", err,
+	)
+	panic(http.ErrAbortHandler)
+}
+
+// allowExport lets a response that streams every job take as long as the
+// export timeout allows, rather than being cut off by the server's write
+// timeout
+func allowExport(context *gin.Context) {
+	deadline := time.Now().Add(settings(context).Database.ExportTimeout.Duration())
+	err := http.NewResponseController(context.Writer).SetWriteDeadline(deadline)
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		context.Error(err)
+	}
+}
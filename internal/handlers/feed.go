@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/Ademayowa/job-board/internal/feed"
+	"github.com/Ademayowa/job-board/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFeedItems caps the number of jobs included in a feed
+const maxFeedItems = 100
+
+// Get the most recent jobs as an RSS feed
+func getRSSFeed(context *gin.Context) {
+	serveFeed(context, "application/rss+xml; charset=utf-8", feed.RSS)
+}
+
+// Get the most recent jobs as an Atom feed
+func getAtomFeed(context *gin.Context) {
+	serveFeed(context, "application/atom+xml; charset=utf-8", feed.Atom)
+}
+
+// serveFeed renders the most recent jobs with the given feed builder.
+// It answers conditional requests with 304 Not Modified so feed readers
+// polling for changes don't download the same document again.
+func serveFeed(context *gin.Context, contentType string, build func(feed.Channel, []models.Job, feed.LinkFunc) ([]byte, error)) {
+	limit, err := strconv.Atoi(context.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > maxFeedItems {
+		limit = maxFeedItems
+	}
+
+	baseURL, err := requestBaseURL(context)
+	if err != nil {
+		serverError(context, "could not determine host URL", err)
+		return
+	}
+
+	jobs, err := models.GetJobsSortedByRecent(context.Request.Context(), context.Query("query"), limit)
+	if err != nil {
+		serverError(context, "could not fetch jobs", err)
+		return
+	}
+
+	site := settings(context).Site
+	channel := feed.Channel{
+		Title:       site.Name,
+		Description: "The latest jobs posted on " + site.Name,
+		Link:        baseURL + "/",
+		Self:        baseURL + context.Request.URL.RequestURI(),
+	}
+	link := func(jobId string) string {
+		url, _ := jobDetailsURL(context, jobId)
+		return url
+	}
+
+	body, err := build(channel, jobs, link)
+	if err != nil {
+		serverError(context, "could not build feed", err)
+		return
+	}
+
+	// Last-Modified can't tell when a job drops out of the feed, so the
+	// ETag catches the changes it would miss
+	sum := sha256.Sum256(body)
+	context.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	context.Header("Content-Type", contentType)
+	context.Header("Cache-Control", "public, max-age=300")
+
+	http.ServeContent(context.Writer, context.Request, "", feed.LastModified(jobs), bytes.NewReader(body))
+}
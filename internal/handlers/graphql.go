@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Ademayowa/job-board/internal/graph"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Run a GraphQL request. Errors in the query are reported in the result
+// the GraphQL way, so the response is a 200 unless the request can't be
+// read at all.
+func graphQL(schema *graph.Schema) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var request graph.Request
+		if err := context.ShouldBindJSON(&request); err != nil {
+			response.Error(context, http.StatusBadRequest, "could not parse GraphQL request")
+			return
+		}
+
+		ctx := graph.WithViewer(context.Request.Context(), graph.Viewer{ID: user(context), Role: role(context)})
+		context.JSON(http.StatusOK, schema.Execute(ctx, request))
+	}
+}
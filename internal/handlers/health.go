@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	stdcontext "context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/buildinfo"
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds how long a readiness check may wait on the database
+const readinessTimeout = 2 * time.Second
+
+// getHealth reports that the process is alive. It checks nothing else,
+// so a slow database doesn't get the process restarted.
+func getHealth(context *gin.Context) {
+	context.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// getReadiness reports whether the server can take traffic: it isn't
+// shutting down, the database answers and its schema is up to date
+func getReadiness(context *gin.Context) {
+	if dependencies(context).ShuttingDown.Load() {
+		context.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+
+	checks := gin.H{"database": "ok", "migrations": "ok"}
+	ready := true
+
+	ctx, cancel := stdcontext.WithTimeout(context.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	// Probes are answered to anyone, so why a check failed is only logged
+	logger := logging.FromContext(context.Request.Context())
+	if err := db.DB.PingContext(ctx); err != nil {
+		logger.Warn("readiness check failed", "check", "database", "error", err)
+		checks["database"] = "failed"
+		checks["migrations"] = "unknown"
+		ready = false
+	} else if pending, err := db.PendingMigrations(ctx); err != nil {
+		logger.Warn("readiness check failed", "check", "migrations", "error", err)
+		checks["migrations"] = "failed"
+		ready = false
+	} else if len(pending) > 0 {
+		checks["migrations"] = strconv.Itoa(len(pending)) + " pending"
+		ready = false
+	}
+
+	if !ready {
+		context.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": checks})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+}
+
+// getVersion reports the build that is running
+func getVersion(context *gin.Context) {
+	context.JSON(http.StatusOK, buildinfo.Get())
+}
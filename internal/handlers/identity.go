@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Ademayowa/job-board/internal/auth"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// user returns the authenticated user making the request, or "" if the
+// request is anonymous
+func user(context *gin.Context) string {
+	return auth.FromContext(context).User
+}
+
+// actor returns who is making the request, for the audit log
+func actor(context *gin.Context) string {
+	if id := user(context); id != "" {
+		return id
+	}
+	return models.AnonymousActor
+}
+
+// role returns the role the user is acting in, defaulting to employer.
+// Anonymous users have no role.
+func role(context *gin.Context) string {
+	identity := auth.FromContext(context)
+	if identity.User == "" {
+		return ""
+	}
+
+	switch identity.Role {
+	case models.RoleReviewer, models.RoleAdmin:
+		return identity.Role
+	default:
+		return models.RoleEmployer
+	}
+}
+
+// canView reports whether the user making the request may see the job
+func canView(context *gin.Context, job models.Job) bool {
+	return models.CanView(job, user(context), role(context))
+}
+
+// requireUser rejects anonymous requests. On failure it writes the error
+// response and returns false.
+func requireUser(context *gin.Context) bool {
+	if user(context) == "" {
+		response.Error(context, http.StatusUnauthorized, "authentication required")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ademayowa/job-board/internal/metrics"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+	"github.com/Ademayowa/job-board/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize caps the size of an import request body (10 MB)
+const maxImportSize = 10 << 20
+
+// dutiesSeparator splits the duties column of a CSV file into a list.
+// It matches the separator used by the CSV export.
+const dutiesSeparator = ";"
+
+// importFields are the job fields a CSV column can be mapped onto
+var importFields = []string{"title", "description", "location", "salary", "duties", "url"}
+
+// importRow is a job parsed from one line of an import file
+type importRow struct {
+	Line int
+	Job  models.Job
+	Err  error
+}
+
+// importError reports why a line of an import file was rejected
+type importError struct {
+	Line       int               `json:"line"`
+	Error      string            `json:"error"`
+	Fields     validation.Errors `json:"fields,omitempty"`
+	Duplicates []string          `json:"duplicates,omitempty"`
+}
+
+// Import modes: an atomic import saves every row or none, a partial one
+// saves the rows it can
+const (
+	importAtomic  = "atomic"
+	importPartial = "partial"
+)
+
+// Import many jobs from a CSV or NDJSON file
+func importJobs(context *gin.Context) {
+	if !requireUser(context) {
+		return
+	}
+
+	format := importFormat(context)
+	dryRun := context.Query("dry_run") == "true"
+
+	mode := context.DefaultQuery("mode", importAtomic)
+	if mode != importAtomic && mode != importPartial {
+		response.Error(context, http.StatusBadRequest, "mode must be atomic or partial")
+		return
+	}
+	atomic := mode == importAtomic
+
+	body := http.MaxBytesReader(context.Writer, context.Request.Body, maxImportSize)
+
+	var rows []importRow
+	var err error
+
+	switch format {
+	case "csv":
+		rows, err = parseCSVJobs(body, context.QueryMap("map"))
+	case "ndjson":
+		rows, err = parseNDJSONJobs(body)
+	default:
+		response.Error(context, http.StatusUnsupportedMediaType, "import format must be csv or ndjson")
+		return
+	}
+	if err != nil {
+		response.Error(context, http.StatusBadRequest, "could not parse import file: "+err.Error())
+		return
+	}
+
+	// Validate every row the same way createJob validates a single job
+	jobs := []models.Job{}
+	lines := []int{}
+	rowErrors := []importError{}
+
+	for _, row := range rows {
+		if row.Err == nil {
+			row.Err = row.Job.Validate()
+		}
+		if row.Err != nil {
+			rowError := importError{Line: row.Line, Error: row.Err.Error()}
+			errors.As(row.Err, &rowError.Fields)
+			rowErrors = append(rowErrors, rowError)
+			continue
+		}
+		checkSpam(dependencies(context).SpamFilter, &row.Job)
+		jobs = append(jobs, row.Job)
+		lines = append(lines, row.Line)
+	}
+
+	report := gin.H{
+		"dry_run":  dryRun,
+		"total":    len(rows),
+		"valid":    len(jobs),
+		"invalid":  len(rowErrors),
+		"imported": 0,
+		"errors":   rowErrors,
+	}
+
+	// In atomic mode a single bad row rejects the whole file
+	if atomic && len(rowErrors) > 0 {
+		if !response.Enveloped(context) {
+			report["message"] = "import rejected, no jobs were saved"
+			context.JSON(http.StatusUnprocessableEntity, report)
+			return
+		}
+
+		problem := response.NewProblem(http.StatusUnprocessableEntity, "import rejected, no jobs were saved")
+		response.Fail(context, problem.With("total", len(rows)).With("invalid", len(rowErrors)).With("rows", rowErrors))
+		return
+	}
+
+	if dryRun {
+		response.Message(context, http.StatusOK, "dry run, no jobs were saved", "", report)
+		return
+	}
+
+	skipped, err := models.ImportJobs(context.Request.Context(), jobs, user(context), settings(context).Moderation.DuplicatePolicy, !atomic)
+	var duplicateErr *models.DuplicateError
+	if errors.As(err, &duplicateErr) {
+		response.Fail(context, response.NewProblem(http.StatusConflict, "a similar job was already posted, no jobs were saved").With("duplicates", duplicateErr.JobIDs))
+		return
+	}
+	if errors.Is(err, models.ErrEmployerBanned) {
+		response.Error(context, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		serverError(context, "could not import jobs", err)
+		return
+	}
+
+	// Rows a partial import couldn't save are reported like invalid ones
+	jobIDs := []string{}
+	for i, job := range jobs {
+		if skipped[i] == nil {
+			jobIDs = append(jobIDs, job.ID)
+			continue
+		}
+
+		rowError := importError{Line: lines[i], Error: skipped[i].Error()}
+		if errors.As(skipped[i], &duplicateErr) {
+			rowError.Error = models.ErrDuplicateJob.Error()
+			rowError.Duplicates = duplicateErr.JobIDs
+		}
+		rowErrors = append(rowErrors, rowError)
+	}
+	sort.Slice(rowErrors, func(i, j int) bool { return rowErrors[i].Line < rowErrors[j].Line })
+
+	metrics.JobsCreated.WithLabelValues("import").Add(float64(len(jobIDs)))
+	report["valid"] = len(jobIDs)
+	report["invalid"] = len(rowErrors)
+	report["errors"] = rowErrors
+	report["imported"] = len(jobIDs)
+	report["job_ids"] = jobIDs
+	response.Message(context, http.StatusCreated, "jobs imported", "", report)
+}
+
+// importFormat picks the import format from the query string or the content type
+func importFormat(context *gin.Context) string {
+	if format := context.Query("format"); format != "" {
+		return strings.ToLower(format)
+	}
+
+	switch context.ContentType() {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return "ndjson"
+	}
+
+	return ""
+}
+
+// parseCSVJobs reads jobs from a CSV file with a header row.
+// Columns are matched to job fields by name unless mapping says otherwise,
+// e.g. map[title]=Job Title maps the "Job Title" column onto the job title.
+func parseCSVJobs(r io.Reader, mapping map[string]string) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file is empty")
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	// Work out which column holds each job field
+	fieldColumns := map[string]int{}
+	for _, field := range importFields {
+		name := field
+		if mapped, ok := mapping[field]; ok {
+			name = mapped
+		}
+		if i, ok := columns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			fieldColumns[field] = i
+		} else if _, ok := mapping[field]; ok {
+			return nil, fmt.Errorf("column %q mapped to %s not found", name, field)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, importRow{Line: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{Line: line}
+		row.Job, row.Err = csvRecordToJob(record, fieldColumns)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// csvRecordToJob builds a job from a CSV record
+func csvRecordToJob(record []string, fieldColumns map[string]int) (models.Job, error) {
+	var job models.Job
+
+	value := func(field string) string {
+		i, ok := fieldColumns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	job.Title = value("title")
+	job.Description = value("description")
+	job.Location = value("location")
+	job.Url = value("url")
+
+	if salary := value("salary"); salary != "" {
+		parsed, err := strconv.ParseFloat(salary, 64)
+		if err != nil {
+			return job, fmt.Errorf("salary: %q is not a number", salary)
+		}
+		job.Salary = parsed
+	}
+
+	if duties := value("duties"); duties != "" {
+		for _, duty := range strings.Split(duties, dutiesSeparator) {
+			if duty = strings.TrimSpace(duty); duty != "" {
+				job.Duties = append(job.Duties, duty)
+			}
+		}
+	}
+
+	return job, nil
+}
+
+// parseNDJSONJobs reads one JSON encoded job per line, skipping blank lines
+func parseNDJSONJobs(r io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportSize)
+
+	var rows []importRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := importRow{Line: line}
+		row.Err = json.Unmarshal(text, &row.Job)
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
@@ -1,29 +1,115 @@
 package handlers
 
 import (
+	stdcontext "context"
 	"encoding/json"
-	"math"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
-	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/logging"
+	"github.com/Ademayowa/job-board/internal/metrics"
 	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+	"github.com/Ademayowa/job-board/internal/validation"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Create a job
 func createJob(context *gin.Context) {
-	var job models.Job
+	if !requireUser(context) {
+		return
+	}
 
-	err := context.ShouldBindJSON(&job)
+	job, ok := bindJob(context)
+	if !ok {
+		return
+	}
+
+	checkSpam(dependencies(context).SpamFilter, &job)
+
+	err := job.Save(context.Request.Context(), user(context), settings(context).Moderation.DuplicatePolicy)
+	var duplicateErr *models.DuplicateError
+	if errors.As(err, &duplicateErr) {
+		response.Fail(context, response.NewProblem(http.StatusConflict, "a similar job was already posted").With("duplicates", duplicateErr.JobIDs))
+		return
+	}
+	if errors.Is(err, models.ErrEmployerBanned) {
+		response.Error(context, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "could not parse job data"})
+		serverError(context, "could not create job", err)
+		return
+	}
+
+	metrics.JobsCreated.WithLabelValues("api").Inc()
+
+	if job.Status == models.StatusHeld {
+		response.Message(context, http.StatusCreated, "job held for moderation", "job", presentJob(context, job))
+		return
+	}
+
+	response.Message(context, http.StatusCreated, "job created", "job", presentJob(context, job))
+}
+
+// bindJob parses and validates the job in the request body.
+// On failure it writes the error response and returns false.
+func bindJob(context *gin.Context) (models.Job, bool) {
+	var job models.Job
+
+	if err := context.ShouldBindJSON(&job); err != nil {
+		response.Error(context, http.StatusBadRequest, "could not parse job data")
+		return job, false
+	}
+
+	if err := job.Validate(); err != nil {
+		respondInvalid(context, "job data is invalid", err)
+		return job, false
+	}
+
+	return job, true
+}
+
+// serverError logs err with the request's context and responds with a
+// 500 problem carrying only message, so internals don't leak to clients. A query
+// that ran out of time is a 503 instead, and nothing is sent to a client
+// that has already gone away.
+func serverError(context *gin.Context, message string, err error) {
+	logger := logging.FromContext(context.Request.Context())
+
+	if errors.Is(err, stdcontext.Canceled) && context.Request.Context().Err() != nil {
+		logger.Info("request cancelled by the client", "route", context.FullPath())
+		context.Abort()
 		return
 	}
 
-	job.Save()
-	context.JSON(http.StatusCreated, gin.H{"message": "job created", "job": job})
+	trace.SpanFromContext(context.Request.Context()).RecordError(err)
+	logger.Error(message,
+		"error", err,
+		"method", context.Request.Method,
+		"route", context.FullPath(),
+	)
+
+	if errors.Is(err, stdcontext.DeadlineExceeded) {
+		response.Error(context, http.StatusServiceUnavailable, message+": the database took too long to respond")
+		return
+	}
+	response.Error(context, http.StatusInternalServerError, message)
+}
+
+// respondInvalid reports the fields that failed validation
+func respondInvalid(context *gin.Context, message string, err error) {
+	var validationErrors validation.Errors
+	if !errors.As(err, &validationErrors) {
+		response.Error(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Invalid(context, message, validationErrors)
 }
 
 // Fetch all jobs
@@ -43,86 +129,119 @@ func getJobs(context *gin.Context) {
 	}
 
 	// Get all jobs with filters and pagination
-	jobs, total, err := models.GetAllJobs(filterTitle, page, limit)
+	jobs, total, err := models.GetAllJobs(context.Request.Context(), filterTitle, page, limit)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "could not fetch jobs"})
+		serverError(context, "could not fetch jobs", err)
 		return
 	}
 
-	// Calculate total pages
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	// Track searches that find nothing, they show what people miss
+	if strings.TrimSpace(filterTitle) != "" {
+		metrics.Searches.Inc()
+		if total == 0 {
+			metrics.SearchesWithoutResults.Inc()
+		}
+	}
 
-	// Return jobs with the metadata(all jobs in the database & pagination)
-	context.JSON(http.StatusOK, gin.H{
-		"data": jobs,
-		"metadata": gin.H{
-			"current_page": page,
-			"per_page":     limit,
-			"total":        total,
-			"total_pages":  totalPages,
-		},
-	})
+	// Return the page of jobs with where it sits among all of them
+	response.Page(context, presentJobs(context, jobs), response.NewPagination(page, limit, total))
 }
 
 // Fetch a single job
 func getJob(context *gin.Context) {
 	jobId := context.Param("id")
 
-	job, err := models.GetJobByID(jobId)
+	// Show the job as it looked at an earlier time
+	if at := context.Query("at"); at != "" {
+		getJobAt(context, jobId, at)
+		return
+	}
+
+	job, err := models.GetJobByID(context.Request.Context(), jobId)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "could not fetch job"})
+		serverError(context, "could not fetch job", err)
+		return
+	}
+
+	if !canView(context, job) {
+		response.Error(context, http.StatusNotFound, "job not found")
+		return
+	}
+
+	// Search engines ask for the schema.org representation
+	if wantsJSONLD(context) {
+		renderJobPosting(context, job)
 		return
 	}
 
-	context.JSON(http.StatusOK, job)
+	context.Header("Vary", "Accept")
+	response.OK(context, presentJob(context, job))
 }
 
 // Delete a job
 func deleteJob(context *gin.Context) {
+	if !requireUser(context) {
+		return
+	}
+
 	jobId := context.Param("id")
 
-	job, err := models.GetJobByID(jobId)
+	job, err := models.GetJobByID(context.Request.Context(), jobId)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "could not fetch job"})
+		serverError(context, "could not fetch job", err)
 		return
 	}
 
-	err = job.Delete()
+	if !models.CanEdit(job, user(context), role(context)) {
+		response.Error(context, http.StatusForbidden, "only the job's owner and admins can delete it")
+		return
+	}
+
+	err = job.Delete(context.Request.Context(), actor(context))
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "could not delete job"})
+		serverError(context, "could not delete job", err)
 		return
 	}
 
-	context.JSON(http.StatusOK, gin.H{"message": "job deleted successfully"})
+	response.Message(context, http.StatusOK, "job deleted successfully", "", nil)
 }
 
 // Update a job
 func updateJob(context *gin.Context) {
+	if !requireUser(context) {
+		return
+	}
+
 	// Extract job ID from the URL
 	jobId := context.Param("id")
 
 	// Parse the request body to get the updated job data
-	var updatedJob models.Job
-	if err := context.ShouldBindJSON(&updatedJob); err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body" + err.Error()})
+	updatedJob, ok := bindJob(context)
+	if !ok {
 		return
 	}
 
+	checkSpam(dependencies(context).SpamFilter, &updatedJob)
+
 	// Convert Duties field to JSON for database storage
 	dutiesJSON, err := json.Marshal(updatedJob.Duties)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "error processing duties field"})
+		serverError(context, "error processing duties field", err)
 		return
 	}
 
 	// Update job in the database
-	err = models.UpdateJobByID(jobId, updatedJob, string(dutiesJSON))
+	err = models.UpdateJobByID(context.Request.Context(), jobId, updatedJob, string(dutiesJSON), actor(context), role(context))
+	if errors.Is(err, models.ErrForbidden) {
+		response.Error(context, http.StatusForbidden, "only the job's owner and admins can edit it")
+		return
+	}
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "could not update job"})
+		serverError(context, "could not update job", err)
 		return
 	}
 
-	context.JSON(http.StatusOK, gin.H{"message": "job updated successfully"})
+	response.Message(context, http.StatusOK, "job updated successfully", "", nil)
 }
 
 // Get jobs sorted by most recent
@@ -130,13 +249,13 @@ func GetRecentJobs(context *gin.Context) {
 	limitParam := context.DefaultQuery("limit", "10")
 	limit, _ := strconv.Atoi(limitParam)
 
-	jobs, err := models.GetJobsSortedByRecent(limit)
+	jobs, err := models.GetJobsSortedByRecent(context.Request.Context(), context.Query("query"), limit)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch recent jobs"})
+		serverError(context, "failed to fetch recent jobs", err)
 		return
 	}
 
-	context.JSON(http.StatusOK, jobs)
+	response.OK(context, presentJobs(context, jobs))
 }
 
 // Get jobs sorted by highest salary
@@ -144,31 +263,52 @@ func GetHighestSalaryJobs(context *gin.Context) {
 	limitParam := context.DefaultQuery("limit", "10")
 	limit, _ := strconv.Atoi(limitParam)
 
-	jobs, err := models.GetJobsSortedBySalary(limit)
+	jobs, err := models.GetJobsSortedBySalary(context.Request.Context(), limit)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch highest salary jobs"})
+		serverError(context, "failed to fetch highest salary jobs", err)
 		return
 	}
 
-	context.JSON(http.StatusOK, jobs)
+	response.OK(context, presentJobs(context, jobs))
 }
 
 // ShareJobLink returns a shareable link for a job post
 func ShareJobLink(context *gin.Context) {
 	jobId := context.Param("id")
 
-	baseURL := context.Request.Host
-	if baseURL == "" {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": "could not determine host URL"})
+	shareableLink, err := jobDetailsURL(context, jobId)
+	if err != nil {
+		serverError(context, "could not determine host URL", err)
+		return
+	}
+
+	if !response.Enveloped(context) {
+		context.JSON(http.StatusOK, shareableLink)
 		return
 	}
+	response.OK(context, gin.H{"url": shareableLink})
+}
+
+// jobDetailsURL builds an absolute link to the frontend job details page
+func jobDetailsURL(context *gin.Context, jobId string) (string, error) {
+	baseURL, err := requestBaseURL(context)
+	if err != nil {
+		return "", err
+	}
+	// Generate a link to the job details page
+	return baseURL + settings(context).Site.JobDetailsPage + "/" + jobId, nil
+}
+
+// requestBaseURL returns the scheme and host the request was made to
+func requestBaseURL(context *gin.Context) (string, error) {
+	host := context.Request.Host
+	if host == "" {
+		return "", errors.New("could not determine host URL")
+	}
 	// Check if the app runs on http or https
 	scheme := "http"
 	if context.Request.TLS != nil {
 		scheme = "https"
 	}
-	// Generate a shareable link to the job details page
-	shareableLink := scheme + "://" + baseURL + config.JobDetailsPage + "/" + jobId
-
-	context.JSON(http.StatusOK, shareableLink)
+	return scheme + "://" + host, nil
 }
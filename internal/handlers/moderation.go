@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+	"github.com/Ademayowa/job-board/internal/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReportDetails caps the free text of a report
+const maxReportDetails = 2000
+
+// reportRequest is the body of a report against a job
+type reportRequest struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// moderationRequest is the optional body of a moderation decision
+type moderationRequest struct {
+	Comment string `json:"comment"`
+}
+
+// isModerator reports whether the user making the request may moderate jobs
+func isModerator(context *gin.Context) bool {
+	r := role(context)
+	return r == models.RoleReviewer || r == models.RoleAdmin
+}
+
+// Report a job as fraudulent, offensive or otherwise against the rules
+func reportJob(context *gin.Context) {
+	if !requireUser(context) {
+		return
+	}
+
+	jobId := context.Param("id")
+
+	var body reportRequest
+	if err := context.ShouldBindJSON(&body); err != nil {
+		response.Error(context, http.StatusBadRequest, "could not parse report")
+		return
+	}
+
+	err := validation.Validate(
+		validation.Field("reason", body.Reason, validation.Required(), validation.OneOf(models.ReportReasons...)),
+		validation.Field("details", body.Details, validation.MaxLength(maxReportDetails)),
+	)
+	if err != nil {
+		respondInvalid(context, "report is invalid", err)
+		return
+	}
+
+	// Only jobs the user can see can be reported
+	job, err := models.GetJobByID(context.Request.Context(), jobId)
+	if err == nil && !canView(context, job) {
+		err = sql.ErrNoRows
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Error(context, http.StatusNotFound, "job not found")
+		return
+	}
+	if err != nil {
+		serverError(context, "could not fetch job", err)
+		return
+	}
+
+	report, err := models.ReportJob(context.Request.Context(), jobId, user(context), body.Reason, body.Details)
+	if errors.Is(err, models.ErrAlreadyReported) {
+		response.Error(context, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		serverError(context, "could not report job", err)
+		return
+	}
+
+	response.Message(context, http.StatusCreated, "job reported", "report", report)
+}
+
+// Fetch the jobs waiting for a moderator
+func getModerationQueue(context *gin.Context) {
+	if !isModerator(context) {
+		response.Error(context, http.StatusForbidden, "only moderators can view the moderation queue")
+		return
+	}
+
+	items, err := models.GetModerationQueue(context.Request.Context())
+	if err != nil {
+		serverError(context, "could not fetch moderation queue", err)
+		return
+	}
+
+	if !response.Enveloped(context) {
+		context.JSON(http.StatusOK, gin.H{"data": presentModerationQueue(context, items), "total": len(items)})
+		return
+	}
+	response.OK(context, presentModerationQueue(context, items))
+}
+
+// moderateJob returns a handler that takes a moderation decision on a job
+func moderateJob(decision string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		// Banning an employer is reserved to admins
+		if !isModerator(context) || (decision == models.DecisionBanEmployer && role(context) != models.RoleAdmin) {
+			response.Error(context, http.StatusForbidden, "user may not take this decision")
+			return
+		}
+
+		var body moderationRequest
+		if context.Request.ContentLength != 0 {
+			if err := context.ShouldBindJSON(&body); err != nil {
+				response.Error(context, http.StatusBadRequest, "could not parse request body")
+				return
+			}
+		}
+
+		jobId := context.Param("id")
+		moderator := actor(context)
+
+		var message, name string
+		var data interface{}
+		var err error
+		switch decision {
+		case models.DecisionApprove:
+			var job models.Job
+			job, err = models.ApproveModeratedJob(context.Request.Context(), jobId, moderator, body.Comment)
+			message, name, data = "job approved", "job", presentJob(context, job)
+		case models.DecisionRemove:
+			err = models.RemoveModeratedJob(context.Request.Context(), jobId, moderator, body.Comment)
+			message = "job removed"
+		case models.DecisionBanEmployer:
+			var ownerID string
+			ownerID, err = models.BanEmployer(context.Request.Context(), jobId, moderator, body.Comment)
+			message, data = "employer banned", gin.H{"owner_id": ownerID}
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			response.Error(context, http.StatusNotFound, "job not found")
+			return
+		}
+		if errors.Is(err, models.ErrNoOwner) {
+			response.Error(context, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			serverError(context, "could not moderate job", err)
+			return
+		}
+
+		response.Message(context, http.StatusOK, message, name, data)
+	}
+}
+
+// Fetch the log of moderation decisions, optionally for a single job
+func getModerationDecisions(context *gin.Context) {
+	if !isModerator(context) {
+		response.Error(context, http.StatusForbidden, "only moderators can view moderation decisions")
+		return
+	}
+
+	decisions, err := models.GetModerationDecisions(context.Request.Context(), context.Query("job_id"))
+	if err != nil {
+		serverError(context, "could not fetch moderation decisions", err)
+		return
+	}
+
+	response.OK(context, decisions)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseRevision reads a revision number, where "current" means the job as it is now
+func parseRevision(value string) (int, error) {
+	if value == "current" {
+		return models.CurrentRevision, nil
+	}
+
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision < 1 {
+		return 0, errors.New("revision must be a positive number or current")
+	}
+
+	return revision, nil
+}
+
+// getJobAt responds with a job as it looked at an RFC 3339 timestamp.
+// Only those who can see the job as it is now may see how it looked.
+func getJobAt(context *gin.Context, jobId string, at string) {
+	atTime, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		response.Error(context, http.StatusBadRequest, "at must be an RFC 3339 timestamp")
+		return
+	}
+
+	if _, ok := findViewableJob(context, jobId); !ok {
+		return
+	}
+
+	job, err := models.GetJobAt(context.Request.Context(), jobId, atTime)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Error(context, http.StatusNotFound, "job not found")
+		return
+	}
+	if err != nil {
+		serverError(context, "could not fetch job", err)
+		return
+	}
+
+	if !canView(context, job) {
+		response.Error(context, http.StatusNotFound, "job not found")
+		return
+	}
+
+	response.OK(context, presentJob(context, job))
+}
+
+// findViewableJob loads a job the user may see. On failure it writes the
+// error response and returns false; a job the user can't see is reported
+// as not found, like one that doesn't exist.
+func findViewableJob(context *gin.Context, jobId string) (models.Job, bool) {
+	job, err := models.GetJobByID(context.Request.Context(), jobId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !canView(context, job)) {
+		response.Error(context, http.StatusNotFound, "job not found")
+		return job, false
+	}
+	if err != nil {
+		serverError(context, "could not fetch job", err)
+		return job, false
+	}
+
+	return job, true
+}
+
+// Fetch every previous version of a job
+func getJobRevisions(context *gin.Context) {
+	if _, ok := findViewableJob(context, context.Param("id")); !ok {
+		return
+	}
+
+	revisions, err := models.GetJobRevisions(context.Request.Context(), context.Param("id"))
+	if err != nil {
+		serverError(context, "could not fetch revisions", err)
+		return
+	}
+
+	response.OK(context, presentRevisions(context, revisions))
+}
+
+// Fetch a single previous version of a job
+func getJobRevision(context *gin.Context) {
+	revision, err := parseRevision(context.Param("rev"))
+	if err != nil || revision == models.CurrentRevision {
+		response.Error(context, http.StatusBadRequest, "revision must be a positive number")
+		return
+	}
+
+	if _, ok := findViewableJob(context, context.Param("id")); !ok {
+		return
+	}
+
+	jobRevision, err := models.GetJobRevision(context.Request.Context(), context.Param("id"), revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Error(context, http.StatusNotFound, "revision not found")
+		return
+	}
+	if err != nil {
+		serverError(context, "could not fetch revision", err)
+		return
+	}
+
+	response.OK(context, presentRevision(context, jobRevision))
+}
+
+// Compare a revision with another revision or with the job as it is now
+func diffJobRevisions(context *gin.Context) {
+	from, err := parseRevision(context.Param("rev"))
+	if err != nil {
+		response.Error(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	to, err := parseRevision(context.DefaultQuery("to", "current"))
+	if err != nil {
+		response.Error(context, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, ok := findViewableJob(context, context.Param("id")); !ok {
+		return
+	}
+
+	diff, err := models.DiffJobRevisions(context.Request.Context(), context.Param("id"), from, to)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Error(context, http.StatusNotFound, "revision not found")
+		return
+	}
+	if err != nil {
+		serverError(context, "could not compare revisions", err)
+		return
+	}
+
+	response.OK(context, diff)
+}
+
+// Roll a job back to a previous version
+func revertJob(context *gin.Context) {
+	if !requireUser(context) {
+		return
+	}
+
+	revision, err := parseRevision(context.Param("rev"))
+	if err != nil || revision == models.CurrentRevision {
+		response.Error(context, http.StatusBadRequest, "revision must be a positive number")
+		return
+	}
+
+	job, err := models.RevertJob(context.Request.Context(), context.Param("id"), revision, actor(context), role(context))
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Error(context, http.StatusNotFound, "revision not found")
+		return
+	}
+	if errors.Is(err, models.ErrForbidden) {
+		response.Error(context, http.StatusForbidden, "only the job's owner and admins can revert it")
+		return
+	}
+	if err != nil {
+		serverError(context, "could not revert job", err)
+		return
+	}
+
+	response.Message(context, http.StatusOK, "job reverted", "job", presentJob(context, job))
+}
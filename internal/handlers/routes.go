@@ -1,31 +1,213 @@
 package handlers
 
 import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/Ademayowa/job-board/internal/auth"
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/graph"
+	"github.com/Ademayowa/job-board/internal/logging"
+	"github.com/Ademayowa/job-board/internal/metrics"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/ratelimit"
+	"github.com/Ademayowa/job-board/internal/response"
+	"github.com/Ademayowa/job-board/internal/spam"
+	"github.com/Ademayowa/job-board/internal/tracing"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(server *gin.Engine) {
+// Dependencies are the services the routes share with the rest of the
+// process. RegisterRoutes fills in a default for any left nil.
+type Dependencies struct {
+	// SpamFilter scores new jobs and edits before they are saved
+	SpamFilter *spam.Pipeline
+	// RateLimitStore holds the rate limit buckets, such as a RedisStore.
+	// By default every call to RegisterRoutes keeps its own in memory.
+	RateLimitStore ratelimit.Store
+	// ShuttingDown makes /readyz fail once set, so load balancers stop
+	// sending traffic while in-flight requests finish
+	ShuttingDown *atomic.Bool
+}
+
+// withDefaults returns deps with a default for every dependency left nil
+func (deps Dependencies) withDefaults() Dependencies {
+	if deps.SpamFilter == nil {
+		deps.SpamFilter = spam.NewPipeline(spam.NewRules(spam.DefaultConfig()))
+	}
+	if deps.RateLimitStore == nil {
+		deps.RateLimitStore = ratelimit.NewMemoryStore()
+	}
+	if deps.ShuttingDown == nil {
+		deps.ShuttingDown = new(atomic.Bool)
+	}
+	return deps
+}
+
+// settingsKey is the key under which a request keeps the configuration
+// its routes were registered with
+const settingsKey = "settings"
+
+// dependenciesKey is the key under which a request keeps the
+// dependencies its routes were registered with
+const dependenciesKey = "dependencies"
+
+// useSettings hands cfg and deps to the handlers of every request
+func useSettings(cfg config.Config, deps Dependencies) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Set(settingsKey, &cfg)
+		context.Set(dependenciesKey, &deps)
+		context.Next()
+	}
+}
+
+// settings returns the configuration the routes of a request were
+// registered with
+func settings(context *gin.Context) *config.Config {
+	return context.MustGet(settingsKey).(*config.Config)
+}
+
+// dependencies returns the dependencies the routes of a request were
+// registered with
+func dependencies(context *gin.Context) *Dependencies {
+	return context.MustGet(dependenciesKey).(*Dependencies)
+}
+
+// RegisterRoutes registers every route of the API on server, configured by
+// cfg and served with deps
+func RegisterRoutes(server *gin.Engine, cfg config.Config, deps Dependencies) {
+	deps = deps.withDefaults()
+	server.Use(useSettings(cfg, deps))
+
+	// Trace every request, then log it with its request and trace IDs,
+	// and recover from panics
+	server.Use(tracing.Middleware())
+	server.Use(logging.Middleware(slog.Default(), "/healthz", "/readyz", "/metrics"), logging.Recovery())
+
 	// Apply CORS middleware
 	server.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:8080"}, // Allow frontend domain
+		AllowOrigins:     cfg.Server.CORSOrigins, // Allow frontend domains
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-User-ID", "X-User-Role", "X-API-Key", "X-Request-ID", "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "X-Request-ID", "Deprecation", "Sunset", "Link"},
 		AllowCredentials: true,           // Allow cookies or auth headers
 		MaxAge:           12 * time.Hour, // Cache preflight for 12 hours
 	}))
 
-	// Define routes
-	server.GET("/jobs", getJobs)
-	server.POST("/jobs", createJob)
+	// Count and time every request
+	server.Use(metrics.Middleware())
+
+	// Believe who a request says it is from only if it came through the
+	// gateway
+	server.Use(auth.Middleware(cfg.Auth.GatewaySecret, cfg.Auth.APIKeys))
+
+	// Probes for the orchestrator
+	server.GET("/healthz", getHealth)
+	server.GET("/readyz", getReadiness)
+	server.GET("/version", getVersion)
+	server.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// API documentation
+	server.GET("/openapi.json", getOpenAPI)
+	server.GET("/docs", getDocs)
+
+	// Search engines look for the sitemap at the root
+	server.GET("/sitemap.xml", getSitemap)
+
+	// Throttle clients that call the API too often. Every version of a
+	// route shares its limit.
+	store := deps.RateLimitStore
+	limits := cfg.RateLimit.Limits()
+
+	for _, version := range []string{apiV1, apiV2} {
+		base := "/api/" + version
+		registerAPI(server.Group(base, useAPIVersion(version), ratelimit.Middleware(store, limits, ratelimit.ClientKey, base)))
+	}
+
+	// GraphQL sits beside the versioned routes, as its schema evolves
+	// without versions
+	schema := graph.New(graph.Limits{MaxDepth: cfg.GraphQL.MaxDepth, MaxComplexity: cfg.GraphQL.MaxComplexity}, cfg.Moderation.DuplicatePolicy, func(job *models.Job) {
+		checkSpam(deps.SpamFilter, job)
+	})
+	server.POST("/graphql", ratelimit.Middleware(store, limits, ratelimit.ClientKey, ""), graphQL(schema))
+
+	// The routes from before the API was versioned answer as v1 until
+	// they are turned off
+	if cfg.API.LegacyRoutes {
+		legacy := server.Group("/", deprecated(cfg.API, "/api/"+apiV1), useAPIVersion(apiV1))
+		legacy.Use(ratelimit.Middleware(store, limits, ratelimit.ClientKey, ""))
+		registerAPI(legacy)
+	}
+
+	// Unknown routes under v2 answer with a problem like every other error
+	server.NoRoute(notFound)
+}
+
+// notFound reports that no route matches a request to v2. Elsewhere gin
+// answers with its plain 404, as it always has.
+func notFound(context *gin.Context) {
+	if !strings.HasPrefix(context.Request.URL.Path, "/api/"+apiV2+"/") {
+		return
+	}
+
+	response.UseEnvelope(context)
+	response.Error(context, http.StatusNotFound, "no route matches "+context.Request.Method+" "+context.Request.URL.Path)
+}
+
+// registerAPI registers the routes every version of the API serves on api
+func registerAPI(api *gin.RouterGroup) {
+	api.GET("/jobs", getJobs)
+	api.POST("/jobs", createJob)
+	api.POST("/jobs/import", importJobs)
+	api.GET("/jobs/export", exportJobs)
+
+	api.GET("/jobs/recent", GetRecentJobs)
+	api.GET("/jobs/highest-salary", GetHighestSalaryJobs)
+	api.GET("/jobs/feed.rss", getRSSFeed)
+	api.GET("/jobs/feed.atom", getAtomFeed)
+
+	api.GET("/jobs/:id", getJob)
+	api.GET("/jobs/:id/jsonld", getJobJSONLD)
+	api.DELETE("/jobs/:id", deleteJob)
+	api.PUT("/jobs/:id", updateJob)
+	api.GET("/jobs/:id/history", getJobHistory)
+	api.POST("/jobs/:id/restore", restoreJob)
+
+	// Publishing workflow
+	api.POST("/jobs/:id/submit", transitionJob(models.ActionSubmit))
+	api.POST("/jobs/:id/withdraw", transitionJob(models.ActionWithdraw))
+	api.POST("/jobs/:id/approve", transitionJob(models.ActionApprove))
+	api.POST("/jobs/:id/reject", transitionJob(models.ActionReject))
+	api.POST("/jobs/:id/publish", transitionJob(models.ActionPublish))
+	api.POST("/jobs/:id/close", transitionJob(models.ActionClose))
+	api.POST("/jobs/:id/release", transitionJob(models.ActionRelease))
+
+	api.GET("/jobs/:id/revisions", getJobRevisions)
+	api.GET("/jobs/:id/revisions/:rev", getJobRevision)
+	api.GET("/jobs/:id/revisions/:rev/diff", diffJobRevisions)
+	api.POST("/jobs/:id/revisions/:rev/revert", revertJob)
+
+	api.POST("/jobs/:id/reports", reportJob)
+
+	api.GET("/audit", getAuditLog)
 
-	server.GET("/jobs/recent", GetRecentJobs)
-	server.GET("/jobs/highest-salary", GetHighestSalaryJobs)
+	api.GET("/moderation/queue", getModerationQueue)
+	api.POST("/moderation/queue/:id/approve", moderateJob(models.DecisionApprove))
+	api.POST("/moderation/queue/:id/remove", moderateJob(models.DecisionRemove))
+	api.POST("/moderation/queue/:id/ban-employer", moderateJob(models.DecisionBanEmployer))
+	api.GET("/moderation/decisions", getModerationDecisions)
+	api.GET("/admin/duplicates", getDuplicateClusters)
 
-	server.GET("/jobs/:id", getJob)
-	server.DELETE("/jobs/:id", deleteJob)
-	server.PUT("/jobs/:id", updateJob)
+	api.GET("/webhooks", getWebhooks)
+	api.POST("/webhooks", createWebhook)
+	api.GET("/webhooks/:id", getWebhook)
+	api.PUT("/webhooks/:id", updateWebhook)
+	api.DELETE("/webhooks/:id", deleteWebhook)
+	api.GET("/webhooks/:id/deliveries", getWebhookDeliveries)
+	api.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", redeliverWebhookDelivery)
 }
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+	"github.com/Ademayowa/job-board/internal/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonLDContentType is the media type for JSON-LD documents
+const jsonLDContentType = "application/ld+json"
+
+// errSitemapFull stops the sitemap once it holds the maximum number of URLs
+var errSitemapFull = errors.New("sitemap is full")
+
+// wantsJSONLD reports whether the client asked for JSON-LD
+func wantsJSONLD(context *gin.Context) bool {
+	return strings.Contains(context.GetHeader("Accept"), jsonLDContentType)
+}
+
+// Get a single job as a schema.org JobPosting
+func getJobJSONLD(context *gin.Context) {
+	jobId := context.Param("id")
+
+	job, err := models.GetJobByID(context.Request.Context(), jobId)
+	if err != nil {
+		serverError(context, "could not fetch job", err)
+		return
+	}
+
+	// Only public jobs are published to search engines
+	if job.Status != models.StatusPublished || job.Hidden {
+		response.Error(context, http.StatusNotFound, "job not found")
+		return
+	}
+
+	renderJobPosting(context, job)
+}
+
+// renderJobPosting writes the job as a JSON-LD JobPosting document
+func renderJobPosting(context *gin.Context, job models.Job) {
+	detailsURL, err := jobDetailsURL(context, job.ID)
+	if err != nil {
+		serverError(context, "could not determine host URL", err)
+		return
+	}
+
+	body, err := json.Marshal(seo.NewJobPosting(job, detailsURL, settings(context).Site))
+	if err != nil {
+		serverError(context, "could not encode job", err)
+		return
+	}
+
+	context.Header("Vary", "Accept")
+	context.Data(http.StatusOK, jsonLDContentType+"; charset=utf-8", body)
+}
+
+// Get a sitemap listing the details page of every active job
+func getSitemap(context *gin.Context) {
+	if _, err := requestBaseURL(context); err != nil {
+		serverError(context, "could not determine host URL", err)
+		return
+	}
+
+	context.Header("Content-Type", "application/xml; charset=utf-8")
+	context.Status(http.StatusOK)
+	allowExport(context)
+
+	sitemap, err := seo.NewSitemapWriter(context.Writer)
+	if err != nil {
+		abortDownload(context, err)
+	}
+
+	err = models.EachJob(context.Request.Context(), "", func(job models.Job) error {
+		if job.Expired {
+			return nil
+		}
+		if sitemap.Full() {
+			return errSitemapFull
+		}
+
+		loc, _ := jobDetailsURL(context, job.ID)
+		url := seo.SitemapURL{Loc: loc}
+		if publishedAt := job.PublishedTime(); !publishedAt.IsZero() {
+			url.LastMod = publishedAt.Format("2006-01-02")
+		}
+
+		return sitemap.Write(url)
+	})
+	if err != nil && !errors.Is(err, errSitemapFull) {
+		abortDownload(context, err)
+	}
+
+	if err := sitemap.Close(); err != nil {
+		abortDownload(context, err)
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/spam"
+)
+
+// checkSpam scores a new job or an edit with filter, holding the job for
+// moderation if it looks like spam
+func checkSpam(filter *spam.Pipeline, job *models.Job) {
+	verdict := filter.Check(*job)
+	job.FlagSpam(verdict.Score, verdict.Reasons, verdict.Hold)
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Versions of the API. Every version serves the same routes and models;
+// they differ in how jobs are represented in responses, and v2 wraps
+// responses in an envelope and reports errors as problem details.
+const (
+	apiV1 = "v1"
+	apiV2 = "v2"
+)
+
+// apiVersionKey is the key under which the API version of a request is kept
+const apiVersionKey = "api_version"
+
+// useAPIVersion records the version of the API a route group serves
+func useAPIVersion(version string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Set(apiVersionKey, version)
+		if version != apiV1 {
+			response.UseEnvelope(context)
+		}
+		context.Next()
+	}
+}
+
+// apiVersion returns the version of the API a request was made to
+func apiVersion(context *gin.Context) string {
+	if version := context.GetString(apiVersionKey); version != "" {
+		return version
+	}
+	return apiV1
+}
+
+// deprecated announces on every response of the legacy routes when they
+// were deprecated and when they go away, and links to the same route
+// under successor
+func deprecated(api config.API, successor string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(api.LegacyDeprecated.Unix(), 10)
+	sunset := api.LegacySunset.UTC().Format(http.TimeFormat)
+
+	return func(context *gin.Context) {
+		header := context.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		header.Set("Sunset", sunset)
+		header.Set("Link", "<"+successor+context.Request.URL.Path+`>; rel="successor-version"`)
+		context.Next()
+	}
+}
+
+// jobV2 is how v2 represents a job. The salary carries its currency, each
+// piece of Markdown comes with its rendered HTML and the expiry date is
+// given rather than left to clients to work out.
+type jobV2 struct {
+	ID                 string   `json:"id"`
+	Title              string   `json:"title"`
+	Description        textV2   `json:"description"`
+	Location           string   `json:"location"`
+	Salary             salaryV2 `json:"salary"`
+	Duties             []textV2 `json:"duties"`
+	ApplyURL           string   `json:"apply_url"`
+	Status             string   `json:"status"`
+	OwnerID            string   `json:"owner_id"`
+	Hidden             bool     `json:"hidden"`
+	CreatedAt          string   `json:"created_at"`
+	ExpiresAt          string   `json:"expires_at,omitempty"`
+	Expired            bool     `json:"expired"`
+	PublishAt          string   `json:"publish_at,omitempty"`
+	PublishedAt        string   `json:"published_at,omitempty"`
+	UpdatedAt          string   `json:"updated_at,omitempty"`
+	ReviewComment      string   `json:"review_comment,omitempty"`
+	PossibleDuplicates []string `json:"possible_duplicates,omitempty"`
+}
+
+// textV2 is Markdown written by a user alongside its sanitized HTML
+type textV2 struct {
+	Markdown string `json:"markdown"`
+	HTML     string `json:"html"`
+}
+
+// salaryV2 is an amount of money paid per unit of time
+type salaryV2 struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Unit     string  `json:"unit"`
+}
+
+// newJobV2 returns the v2 representation of job, paid in the currency of
+// site
+func newJobV2(job models.Job, site config.Site) jobV2 {
+	duties := make([]textV2, len(job.Duties))
+	for i, duty := range job.Duties {
+		duties[i].Markdown = duty
+		if i < len(job.DutiesHTML) {
+			duties[i].HTML = job.DutiesHTML[i]
+		}
+	}
+
+	var publishedAt, expiresAt string
+	if at := job.PublishedTime(); !at.IsZero() {
+		publishedAt = at.Format(models.DateFormat)
+	}
+	if at := job.ExpiresAt(); !at.IsZero() {
+		expiresAt = at.Format(models.DateFormat)
+	}
+
+	return jobV2{
+		ID:                 job.ID,
+		Title:              job.Title,
+		Description:        textV2{Markdown: job.Description, HTML: job.DescriptionHTML},
+		Location:           job.Location,
+		Salary:             salaryV2{Amount: job.Salary, Currency: site.SalaryCurrency, Unit: site.SalaryUnit},
+		Duties:             duties,
+		ApplyURL:           job.Url,
+		Status:             job.Status,
+		OwnerID:            job.OwnerID,
+		Hidden:             job.Hidden,
+		CreatedAt:          job.CreatedAt,
+		ExpiresAt:          expiresAt,
+		Expired:            job.Expired,
+		PublishAt:          job.PublishAt,
+		PublishedAt:        publishedAt,
+		UpdatedAt:          job.UpdatedAt,
+		ReviewComment:      job.ReviewComment,
+		PossibleDuplicates: job.PossibleDuplicates,
+	}
+}
+
+// presentJob returns job as represented in the API version of the request
+func presentJob(context *gin.Context, job models.Job) interface{} {
+	if apiVersion(context) == apiV2 {
+		return newJobV2(job, settings(context).Site)
+	}
+	return job
+}
+
+// presentJobs returns jobs as represented in the API version of the request
+func presentJobs(context *gin.Context, jobs []models.Job) interface{} {
+	if apiVersion(context) != apiV2 {
+		return jobs
+	}
+
+	presented := make([]jobV2, len(jobs))
+	for i, job := range jobs {
+		presented[i] = newJobV2(job, settings(context).Site)
+	}
+	return presented
+}
+
+// presentRevision returns a saved version of a job as represented in the
+// API version of the request
+func presentRevision(context *gin.Context, revision models.JobRevision) interface{} {
+	if apiVersion(context) != apiV2 {
+		return revision
+	}
+	return gin.H{
+		"job_id":     revision.JobID,
+		"revision":   revision.Revision,
+		"actor":      revision.Actor,
+		"created_at": revision.CreatedAt,
+		"job":        newJobV2(revision.Job, settings(context).Site),
+	}
+}
+
+// presentRevisions returns saved versions of a job as represented in the
+// API version of the request
+func presentRevisions(context *gin.Context, revisions []models.JobRevision) interface{} {
+	if apiVersion(context) != apiV2 {
+		return revisions
+	}
+
+	presented := make([]interface{}, len(revisions))
+	for i, revision := range revisions {
+		presented[i] = presentRevision(context, revision)
+	}
+	return presented
+}
+
+// presentModerationQueue returns the jobs waiting for a moderator as
+// represented in the API version of the request
+func presentModerationQueue(context *gin.Context, items []models.ModerationItem) interface{} {
+	if apiVersion(context) != apiV2 {
+		return items
+	}
+
+	presented := make([]gin.H, len(items))
+	for i, item := range items {
+		presented[i] = gin.H{
+			"job":          newJobV2(item.Job, settings(context).Site),
+			"spam_score":   item.SpamScore,
+			"spam_reasons": item.SpamReasons,
+			"reports":      item.Reports,
+		}
+	}
+	return presented
+}
+
+// presentDuplicateClusters returns groups of similar jobs as represented
+// in the API version of the request
+func presentDuplicateClusters(context *gin.Context, clusters []models.DuplicateCluster) interface{} {
+	if apiVersion(context) != apiV2 {
+		return clusters
+	}
+
+	presented := make([]gin.H, len(clusters))
+	for i, cluster := range clusters {
+		presented[i] = gin.H{"owner_id": cluster.OwnerID, "jobs": presentJobs(context, cluster.Jobs)}
+	}
+	return presented
+}
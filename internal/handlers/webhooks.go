@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+	"github.com/Ademayowa/job-board/internal/validation"
+	"github.com/Ademayowa/job-board/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// webhookRequest is the body accepted when creating or updating a webhook
+type webhookRequest struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+// validEvents reports whether every event is a known job event
+func validEvents(events []string) bool {
+	for _, event := range events {
+		known := false
+		for _, e := range models.Events {
+			if e == event {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return false
+		}
+	}
+	return true
+}
+
+// requireAdmin rejects anyone but admins, as webhooks receive every job
+// event and make the server call out to any URL. On failure it writes the
+// error response and returns false.
+func requireAdmin(context *gin.Context) bool {
+	if !requireUser(context) {
+		return false
+	}
+	if role(context) != models.RoleAdmin {
+		response.Error(context, http.StatusForbidden, "only admins can manage webhooks")
+		return false
+	}
+	return true
+}
+
+// validTarget reports whether the webhook URL is safe to deliver to.
+// On failure it writes the error response and returns false.
+func validTarget(context *gin.Context, target string) bool {
+	err := validation.Validate(
+		validation.Field("url", target, webhooks.Target(context.Request.Context(), settings(context).Webhooks.AllowPrivateTargets)),
+	)
+	if err != nil {
+		respondInvalid(context, "webhook data is invalid", err)
+		return false
+	}
+	return true
+}
+
+// Create a webhook subscription
+func createWebhook(context *gin.Context) {
+	if !requireAdmin(context) {
+		return
+	}
+
+	var request webhookRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		response.Error(context, http.StatusBadRequest, "could not parse webhook data")
+		return
+	}
+
+	if !validEvents(request.Events) {
+		response.Fail(context, response.NewProblem(http.StatusBadRequest, "unknown event").With("events", models.Events))
+		return
+	}
+
+	if !validTarget(context, request.URL) {
+		return
+	}
+
+	// Generate a signing secret unless the subscriber brought their own
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		secret, err = webhooks.NewSecret()
+		if err != nil {
+			serverError(context, "could not generate secret", err)
+			return
+		}
+	}
+
+	webhook := models.Webhook{URL: request.URL, Events: request.Events, Secret: secret}
+	if err := webhook.Save(context.Request.Context()); err != nil {
+		serverError(context, "could not create webhook", err)
+		return
+	}
+
+	// The secret is only ever shown once, when the webhook is created
+	response.Message(context, http.StatusCreated, "webhook created", "webhook", webhook)
+}
+
+// Fetch all webhooks
+func getWebhooks(context *gin.Context) {
+	if !requireAdmin(context) {
+		return
+	}
+
+	subscriptions, err := models.GetAllWebhooks(context.Request.Context())
+	if err != nil {
+		serverError(context, "could not fetch webhooks", err)
+		return
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	response.OK(context, subscriptions)
+}
+
+// Fetch a single webhook
+func getWebhook(context *gin.Context) {
+	if !requireAdmin(context) {
+		return
+	}
+
+	webhook, err := models.GetWebhookByID(context.Request.Context(), context.Param("id"))
+	if err != nil {
+		response.Error(context, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	webhook.Secret = ""
+	response.OK(context, webhook)
+}
+
+// Update a webhook
+func updateWebhook(context *gin.Context) {
+	if !requireAdmin(context) {
+		return
+	}
+
+	webhook, err := models.GetWebhookByID(context.Request.Context(), context.Param("id"))
+	if err != nil {
+		response.Error(context, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	var request webhookRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		response.Error(context, http.StatusBadRequest, "could not parse webhook data")
+		return
+	}
+
+	if !validEvents(request.Events) {
+		response.Fail(context, response.NewProblem(http.StatusBadRequest, "unknown event").With("events", models.Events))
+		return
+	}
+
+	if !validTarget(context, request.URL) {
+		return
+	}
+
+	webhook.URL = request.URL
+	webhook.Events = request.Events
+	if request.Active != nil {
+		webhook.Active = *request.Active
+	}
+
+	if err := models.UpdateWebhookByID(context.Request.Context(), webhook.ID, webhook); err != nil {
+		serverError(context, "could not update webhook", err)
+		return
+	}
+
+	response.Message(context, http.StatusOK, "webhook updated successfully", "", nil)
+}
+
+// Delete a webhook
+func deleteWebhook(context *gin.Context) {
+	if !requireAdmin(context) {
+		return
+	}
+
+	webhook, err := models.GetWebhookByID(context.Request.Context(), context.Param("id"))
+	if err != nil {
+		response.Error(context, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	if err := webhook.Delete(context.Request.Context()); err != nil {
+		serverError(context, "could not delete webhook", err)
+		return
+	}
+
+	response.Message(context, http.StatusOK, "webhook deleted successfully", "", nil)
+}
+
+// Fetch the delivery log of a webhook
+func getWebhookDeliveries(context *gin.Context) {
+	if !requireAdmin(context) {
+		return
+	}
+
+	webhookId := context.Param("id")
+
+	if _, err := models.GetWebhookByID(context.Request.Context(), webhookId); err != nil {
+		response.Error(context, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	limit, err := strconv.Atoi(context.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+
+	deliveries, err := models.GetWebhookDeliveries(context.Request.Context(), webhookId, limit)
+	if err != nil {
+		serverError(context, "could not fetch deliveries", err)
+		return
+	}
+
+	response.OK(context, deliveries)
+}
+
+// Queue a delivery to be sent again
+func redeliverWebhookDelivery(context *gin.Context) {
+	if !requireAdmin(context) {
+		return
+	}
+
+	delivery, err := models.GetWebhookDelivery(context.Request.Context(), context.Param("id"), context.Param("delivery_id"))
+	if err != nil {
+		response.Error(context, http.StatusNotFound, "delivery not found")
+		return
+	}
+
+	redelivery, err := delivery.Redeliver(context.Request.Context())
+	if err != nil {
+		serverError(context, "could not queue delivery", err)
+		return
+	}
+
+	response.Message(context, http.StatusAccepted, "delivery queued", "delivery", redelivery)
+}
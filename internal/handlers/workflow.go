@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// transitionRequest is the optional body of a workflow action
+type transitionRequest struct {
+	Comment   string    `json:"comment"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+// transitionJob returns a handler that performs a workflow action on a job
+func transitionJob(action string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !requireUser(context) {
+			return
+		}
+
+		var body transitionRequest
+		if context.Request.ContentLength != 0 {
+			if err := context.ShouldBindJSON(&body); err != nil {
+				response.Error(context, http.StatusBadRequest, "could not parse request body")
+				return
+			}
+		}
+
+		job, err := models.TransitionJob(context.Request.Context(), context.Param("id"), models.TransitionRequest{
+			Action:    action,
+			Actor:     actor(context),
+			Role:      role(context),
+			Comment:   body.Comment,
+			PublishAt: body.PublishAt,
+		})
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			response.Error(context, http.StatusNotFound, "job not found")
+		case errors.Is(err, models.ErrForbidden):
+			response.Error(context, http.StatusForbidden, err.Error())
+		case errors.Is(err, models.ErrInvalidTransition):
+			response.Error(context, http.StatusConflict, err.Error())
+		case errors.Is(err, models.ErrCommentRequired):
+			response.Error(context, http.StatusBadRequest, err.Error())
+		case err != nil:
+			serverError(context, "could not "+action+" job", err)
+		default:
+			response.Message(context, http.StatusOK, "job "+job.Status, "job", presentJob(context, job))
+		}
+	}
+}
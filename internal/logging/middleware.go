@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the ID that ties together every log line of a
+// request, across services
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps IDs sent by clients so they can't bloat the logs
+const maxRequestIDLength = 128
+
+// Middleware gives every request an ID, taken from the X-Request-ID
+// header or generated, and echoes it in the response. Handlers find a
+// logger carrying the ID, and the trace ID if the request is traced,
+// through FromContext. Once the request is handled
+// an access log line is written: at error level for server errors and
+// debug level for the probes in quiet, info otherwise.
+func Middleware(logger *slog.Logger, quiet ...string) gin.HandlerFunc {
+	quietRoutes := make(map[string]bool, len(quiet))
+	for _, route := range quiet {
+		quietRoutes[route] = true
+	}
+
+	return func(context *gin.Context) {
+		start := time.Now()
+
+		requestID := context.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		context.Header(RequestIDHeader, requestID)
+
+		requestLogger := logger.With("request_id", requestID)
+		if span := trace.SpanContextFromContext(context.Request.Context()); span.IsValid() {
+			requestLogger = requestLogger.With("trace_id", span.TraceID().String())
+		}
+		context.Request = context.Request.WithContext(WithLogger(context.Request.Context(), requestLogger))
+
+		context.Next()
+
+		status := context.Writer.Status()
+		route := context.FullPath()
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case quietRoutes[route]:
+			level = slog.LevelDebug
+		}
+
+		requestLogger.LogAttrs(context.Request.Context(), level, "request",
+			slog.String("method", context.Request.Method),
+			slog.String("route", route),
+			slog.String("path", context.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", context.Writer.Size()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", context.ClientIP()),
+			slog.String("user_agent", context.Request.UserAgent()),
+		)
+	}
+}
+
+// validRequestID accepts IDs of printable ASCII up to maxRequestIDLength
+// characters, so they can't forge log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Recovery turns a panic in a handler into a 500 response and logs it,
+// with its stack, through the request's logger
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(context *gin.Context, recovered any) {
+		// Handlers abort a response that can't be finished, so the client
+		// sees it fail rather than getting part of it
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+		FromContext(context.Request.Context()).Error("handler panicked",
+			"panic", fmt.Sprint(recovered),
+			"route", context.FullPath(),
+			"stack", string(debug.Stack()),
+		)
+		response.Error(context, http.StatusInternalServerError, "internal server error")
+	})
+}
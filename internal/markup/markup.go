@@ -0,0 +1,113 @@
+package markup
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"golang.org/x/net/html"
+)
+
+// markdown converts the Markdown subset used in job posts. Raw HTML in the
+// source is dropped rather than passed through.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Strikethrough, extension.Linkify),
+)
+
+// policy is the allowlist every rendered document is filtered through.
+// Anything not listed here is removed, whatever the Markdown produced.
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+
+	p.AllowElements("p", "br", "hr", "strong", "em", "del", "blockquote", "pre", "code", "ul", "ol", "li", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("start").Matching(regexp.MustCompile(`^[0-9]+$`)).OnElements("ol")
+
+	// Links may only point at web pages or email addresses and are never
+	// trusted by search engines
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
+
+// Render converts Markdown to sanitized HTML
+func Render(source string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		// Converting into a buffer can't fail, but fall back to escaped text
+		return Sanitize(source)
+	}
+
+	return Sanitize(buf.String())
+}
+
+// RenderInline converts a single line of Markdown, such as a duty, to
+// sanitized HTML without the surrounding paragraph
+func RenderInline(source string) string {
+	rendered := strings.TrimSpace(Render(source))
+
+	inner, ok := strings.CutPrefix(rendered, "<p>")
+	if ok {
+		inner, ok = strings.CutSuffix(inner, "</p>")
+	}
+	if !ok || strings.Contains(inner, "<p>") {
+		return rendered
+	}
+
+	return inner
+}
+
+// Sanitize strips everything from an HTML fragment that the allowlist
+// doesn't permit
+func Sanitize(html string) string {
+	return policy.Sanitize(html)
+}
+
+// blocks are the elements that start a new line in plain text
+var blocks = map[string]bool{
+	"p": true, "br": true, "hr": true, "blockquote": true, "pre": true, "ul": true, "ol": true, "li": true,
+	"h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// PlainText strips the markup from rendered HTML, keeping its text with a
+// line for each paragraph or list item, for places that don't take HTML
+func PlainText(rendered string) string {
+	var b strings.Builder
+
+	tokenizer := html.NewTokenizer(strings.NewReader(rendered))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if tokenizer.Err() != io.EOF {
+				return strings.TrimSpace(b.String())
+			}
+			return collapseLines(b.String())
+		case html.TextToken:
+			b.Write(tokenizer.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			if name, _ := tokenizer.TagName(); blocks[string(name)] {
+				b.WriteString("\n")
+			}
+		}
+	}
+}
+
+// collapseLines trims every line of text and drops the blank ones
+func collapseLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
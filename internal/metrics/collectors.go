@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Connection pool statistics of the database opened by db.InitDB
+var (
+	dbMaxOpen = prometheus.NewDesc(namespace+"_db_max_open_connections",
+		"Maximum number of open connections to the database.", nil, nil)
+	dbOpen = prometheus.NewDesc(namespace+"_db_open_connections",
+		"Connections to the database, in use or idle.", nil, nil)
+	dbInUse = prometheus.NewDesc(namespace+"_db_in_use_connections",
+		"Connections to the database that are in use.", nil, nil)
+	dbIdle = prometheus.NewDesc(namespace+"_db_idle_connections",
+		"Idle connections to the database.", nil, nil)
+	dbWaitCount = prometheus.NewDesc(namespace+"_db_wait_count_total",
+		"Times a query waited for a free connection.", nil, nil)
+	dbWaitDuration = prometheus.NewDesc(namespace+"_db_wait_duration_seconds_total",
+		"Time spent waiting for a free connection.", nil, nil)
+	dbIdleClosed = prometheus.NewDesc(namespace+"_db_idle_closed_total",
+		"Connections closed because too many were idle.", nil, nil)
+	dbLifetimeClosed = prometheus.NewDesc(namespace+"_db_lifetime_closed_total",
+		"Connections closed because they reached their maximum lifetime.", nil, nil)
+)
+
+// dbStatsCollector reads the pool statistics of db.DB when scraped, so it
+// follows the database even if it is reopened
+type dbStatsCollector struct{}
+
+func (dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbMaxOpen
+	ch <- dbOpen
+	ch <- dbInUse
+	ch <- dbIdle
+	ch <- dbWaitCount
+	ch <- dbWaitDuration
+	ch <- dbIdleClosed
+	ch <- dbLifetimeClosed
+}
+
+func (dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	if db.DB == nil {
+		return
+	}
+	stats := db.DB.Stats()
+
+	ch <- prometheus.MustNewConstMetric(dbMaxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbOpen, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(dbInUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(dbIdle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(dbWaitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(dbWaitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(dbIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(dbLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
+
+// publicJobs is the number of published jobs, by whether they have expired
+var publicJobs = prometheus.NewDesc(namespace+"_jobs",
+	"Published jobs, by state: active or expired.", []string{"state"}, nil)
+
+// jobsCollector counts the jobs in the database when scraped
+type jobsCollector struct{}
+
+func (jobsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- publicJobs
+}
+
+func (jobsCollector) Collect(ch chan<- prometheus.Metric) {
+	if db.DB == nil {
+		return
+	}
+
+	active, expired, err := models.CountPublicJobs(context.Background())
+	if err != nil {
+		slog.Error("could not count jobs for metrics", "error", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(publicJobs, prometheus.GaugeValue, float64(active), "active")
+	ch <- prometheus.MustNewConstMetric(publicJobs, prometheus.GaugeValue, float64(expired), "expired")
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric of the job board
+const namespace = "job_board"
+
+// Registry holds every metric exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+// HTTP traffic, labelled by route pattern rather than URL so job IDs
+// don't create a series each
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to handle HTTP requests, by method and route.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+
+	requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being handled.",
+	})
+)
+
+// Business counters
+var (
+	// JobsCreated counts jobs posted, by source: api, graphql or import
+	JobsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_created_total",
+		Help:      "Jobs created, by source.",
+	}, []string{"source"})
+
+	// Searches counts job searches with a query
+	Searches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "searches_total",
+		Help:      "Job searches with a query.",
+	})
+
+	// SearchesWithoutResults counts job searches that found nothing
+	SearchesWithoutResults = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "searches_without_results_total",
+		Help:      "Job searches with a query that found no jobs.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		requestsInFlight,
+		JobsCreated,
+		Searches,
+		SearchesWithoutResults,
+		dbStatsCollector{},
+		jobsCollector{},
+	)
+}
+
+// Middleware records the count and duration of every request
+func Middleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		start := time.Now()
+		context.Next()
+
+		// Requests that matched no route share one label
+		route := context.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		method := context.Request.Method
+		requestsTotal.WithLabelValues(method, route, strconv.Itoa(context.Writer.Status())).Inc()
+		requestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
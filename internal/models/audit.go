@@ -0,0 +1,276 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// ErrJobNotDeleted is returned when restoring a job that still exists
+var ErrJobNotDeleted = errors.New("job is not deleted")
+
+// JobEvent is an entry in the audit log of a job
+type JobEvent struct {
+	ID        int64                  `json:"id"`
+	JobID     string                 `json:"job_id"`
+	Actor     string                 `json:"actor"`
+	Action    string                 `json:"action"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+// FieldChange holds the value of a field before and after a change
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter narrows down the audit log
+type AuditFilter struct {
+	Actor  string
+	JobID  string
+	Action string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// auditedFields are the job fields tracked by the audit log, by JSON name
+var auditedFields = []string{
+	"title", "description", "location", "salary", "duties", "url", "created_at",
+	"status", "owner_id", "publish_at", "published_at", "review_comment", "hidden",
+}
+
+// auditFields returns the audited fields of a job keyed by their JSON name
+func auditFields(job *Job) map[string]interface{} {
+	return map[string]interface{}{
+		"title":          job.Title,
+		"description":    job.Description,
+		"location":       job.Location,
+		"salary":         job.Salary,
+		"duties":         job.Duties,
+		"url":            job.Url,
+		"created_at":     job.CreatedAt,
+		"status":         job.Status,
+		"owner_id":       job.OwnerID,
+		"publish_at":     job.PublishAt,
+		"published_at":   job.PublishedAt,
+		"review_comment": job.ReviewComment,
+		"hidden":         job.Hidden,
+	}
+}
+
+// diffJobs returns the fields that differ between two versions of a job.
+// A nil before or after means the job was created or removed, in which
+// case every field is included.
+func diffJobs(before, after *Job) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+
+	var beforeFields, afterFields map[string]interface{}
+	if before != nil {
+		beforeFields = auditFields(before)
+	}
+	if after != nil {
+		afterFields = auditFields(after)
+	}
+
+	for _, field := range auditedFields {
+		var change FieldChange
+		if beforeFields != nil {
+			change.Before = beforeFields[field]
+		}
+		if afterFields != nil {
+			change.After = afterFields[field]
+		}
+
+		// Compare the JSON encoding so nil and empty duties count as equal
+		beforeJSON, _ := json.Marshal(change.Before)
+		afterJSON, _ := json.Marshal(change.After)
+		if before != nil && after != nil && bytes.Equal(beforeJSON, afterJSON) {
+			continue
+		}
+
+		changes[field] = change
+	}
+
+	return changes
+}
+
+// recordJobEvent appends an entry to the audit log. It must run in the
+// same transaction as the change it describes.
+func recordJobEvent(ctx context.Context, exec execer, actor, action string, before, after *Job) error {
+	jobID := ""
+	if after != nil {
+		jobID = after.ID
+	} else if before != nil {
+		jobID = before.ID
+	}
+
+	changesJSON, err := json.Marshal(diffJobs(before, after))
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO job_events(job_id, actor, action, changes, created_at)
+		VALUES(?, ?, ?, ?, ?)
+	`
+	_, err = exec.ExecContext(ctx, query, jobID, actor, action, string(changesJSON), time.Now().UTC().Format(DateFormat))
+
+	return err
+}
+
+// scanJobEvent reads an event from a row returned by SELECT * FROM job_events
+func scanJobEvent(row rowScanner) (JobEvent, error) {
+	var event JobEvent
+	var changesJSON string
+
+	err := row.Scan(
+		&event.ID,
+		&event.JobID,
+		&event.Actor,
+		&event.Action,
+		&changesJSON,
+		&event.CreatedAt,
+	)
+	if err != nil {
+		return event, err
+	}
+
+	err = json.Unmarshal([]byte(changesJSON), &event.Changes)
+
+	return event, err
+}
+
+// deletedJob rebuilds a deleted job from the audit entry that removed it
+func deletedJob(ctx context.Context, q rowQuerier, id string) (Job, error) {
+	query := "SELECT * FROM job_events WHERE job_id = ? ORDER BY id DESC LIMIT 1"
+	event, err := scanJobEvent(q.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return Job{}, err
+	}
+
+	if event.Action != ActionDelete {
+		return Job{}, ErrJobNotDeleted
+	}
+
+	// The delete entry holds every field as it was before removal,
+	// keyed by the same names the Job JSON encoding uses
+	snapshot := map[string]interface{}{"id": id}
+	for field, change := range event.Changes {
+		snapshot[field] = change.Before
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return Job{}, err
+	}
+
+	var job Job
+	err = json.Unmarshal(snapshotJSON, &job)
+
+	return job, err
+}
+
+// rowQuerier is satisfied by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// GetDeletedJob returns a deleted job as it was when it was deleted. It
+// returns ErrJobNotDeleted if the job still exists.
+func GetDeletedJob(ctx context.Context, id string) (Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	return deletedJob(ctx, db.DB, id)
+}
+
+// Get the audit history of a job, oldest first
+func GetJobHistory(ctx context.Context, jobID string) ([]JobEvent, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	events, _, err := GetAuditLog(ctx, AuditFilter{JobID: jobID})
+	return events, err
+}
+
+// Get audit log entries matching the filter, oldest first, along with
+// the total number of matching entries
+func GetAuditLog(ctx context.Context, filter AuditFilter) ([]JobEvent, int, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT * FROM job_events WHERE 1=1"
+	args := []interface{}{}
+
+	if filter.JobID != "" {
+		query += " AND job_id = ?"
+		args = append(args, filter.JobID)
+	}
+
+	if filter.Actor != "" {
+		query += " AND actor = ?"
+		args = append(args, filter.Actor)
+	}
+
+	if filter.Action != "" {
+		query += " AND action = ?"
+		args = append(args, filter.Action)
+	}
+
+	// Timestamps are stored in UTC so they compare correctly as text
+	if !filter.From.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, filter.From.UTC().Format(DateFormat))
+	}
+
+	if !filter.To.IsZero() {
+		query += " AND created_at <= ?"
+		args = append(args, filter.To.UTC().Format(DateFormat))
+	}
+
+	countQuery := "SELECT COUNT(*) FROM (" + query + ") AS count_query"
+
+	var total int
+	if err := db.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query += " ORDER BY id ASC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []JobEvent{}
+	for rows.Next() {
+		event, err := scanJobEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+
+	return events, total, rows.Err()
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/fingerprint"
+)
+
+// What Save does when an owner posts a near-duplicate of one of their jobs
+const (
+	DuplicateWarn   = "warn"
+	DuplicateReject = "reject"
+)
+
+// DuplicateWindowDays is how far back a new job is compared with the
+// owner's earlier jobs
+const DuplicateWindowDays = 30
+
+// DuplicateDistance is the largest number of fingerprint bits two jobs
+// may differ in and still count as duplicates
+const DuplicateDistance = 8
+
+// ErrDuplicateJob is returned when a near-duplicate is rejected
+var ErrDuplicateJob = errors.New("job is a near-duplicate of an existing job")
+
+// DuplicateError lists the jobs a rejected job duplicates
+type DuplicateError struct {
+	JobIDs []string
+}
+
+func (e *DuplicateError) Error() string {
+	return ErrDuplicateJob.Error() + ": " + strings.Join(e.JobIDs, ", ")
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicateJob
+}
+
+// DuplicateCluster is a group of one owner's jobs that look alike
+type DuplicateCluster struct {
+	OwnerID string `json:"owner_id"`
+	Jobs    []Job  `json:"jobs"`
+}
+
+// fingerprint returns the SimHash of the job's content
+func (job *Job) fingerprint() uint64 {
+	return fingerprint.SimHash(job.Title, job.Location, job.Description)
+}
+
+// findDuplicates returns the IDs of the owner's recent open jobs that
+// look like the given job
+func findDuplicates(ctx context.Context, tx *sql.Tx, job Job) ([]string, error) {
+	query := `
+		SELECT id, fingerprint, title, location, description FROM jobs
+		WHERE owner_id = ? AND status != ? AND id != ?
+		AND julianday(created_at) >= julianday('now', ?)
+		ORDER BY created_at
+	`
+	rows, err := tx.QueryContext(ctx, query, job.OwnerID, StatusClosed, job.ID, "-"+strconv.Itoa(DuplicateWindowDays)+" days")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	target := job.fingerprint()
+
+	var duplicates []string
+	for rows.Next() {
+		var other Job
+		var stored sql.NullInt64
+		if err := rows.Scan(&other.ID, &stored, &other.Title, &other.Location, &other.Description); err != nil {
+			return nil, err
+		}
+
+		// Jobs saved before fingerprinting are fingerprinted now
+		fp := uint64(stored.Int64)
+		if !stored.Valid {
+			fp = other.fingerprint()
+		}
+
+		if fingerprint.Distance(target, fp) <= DuplicateDistance {
+			duplicates = append(duplicates, other.ID)
+		}
+	}
+
+	return duplicates, rows.Err()
+}
+
+// GetDuplicateClusters groups every open job posted within the duplicate
+// window with the owner's other jobs it looks like. Jobs without an owner
+// or a look-alike are left out.
+func GetDuplicateClusters(ctx context.Context) ([]DuplicateCluster, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + jobColumns + `, fingerprint FROM jobs
+		WHERE status != ? AND owner_id != ''
+		AND julianday(created_at) >= julianday('now', ?)
+		ORDER BY owner_id, created_at
+	`
+	rows, err := db.DB.QueryContext(ctx, query, StatusClosed, "-"+strconv.Itoa(DuplicateWindowDays)+" days")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byOwner := map[string][]fingerprintedJob{}
+	var owners []string
+	for rows.Next() {
+		var stored sql.NullInt64
+		job, err := scanJob(extraColumns{rows, []interface{}{&stored}})
+		if err != nil {
+			return nil, err
+		}
+
+		// Jobs saved before fingerprinting are fingerprinted now
+		fp := uint64(stored.Int64)
+		if !stored.Valid {
+			fp = job.fingerprint()
+		}
+
+		if _, ok := byOwner[job.OwnerID]; !ok {
+			owners = append(owners, job.OwnerID)
+		}
+		byOwner[job.OwnerID] = append(byOwner[job.OwnerID], fingerprintedJob{job, fp})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	clusters := []DuplicateCluster{}
+	for _, owner := range owners {
+		for _, jobs := range clusterJobs(byOwner[owner]) {
+			clusters = append(clusters, DuplicateCluster{OwnerID: owner, Jobs: jobs})
+		}
+	}
+
+	return clusters, nil
+}
+
+// fingerprintedJob is a job with its stored fingerprint
+type fingerprintedJob struct {
+	Job
+	simHash uint64
+}
+
+// extraColumns scans the columns a query selects after jobColumns into
+// dest, so the row can be read with scanJob
+type extraColumns struct {
+	row  rowScanner
+	dest []interface{}
+}
+
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.dest...)...)
+}
+
+// clusterJobs links every pair of look-alike jobs and returns the groups
+// of two or more jobs this forms, keeping the jobs' order
+func clusterJobs(jobs []fingerprintedJob) [][]Job {
+	// Union-find over job indexes
+	parent := make([]int, len(jobs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range jobs {
+		for j := i + 1; j < len(jobs); j++ {
+			if fingerprint.Distance(jobs[i].simHash, jobs[j].simHash) <= DuplicateDistance {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := map[int][]Job{}
+	var roots []int
+	for i, job := range jobs {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], job.Job)
+	}
+
+	var clusters [][]Job
+	for _, root := range roots {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, groups[root])
+		}
+	}
+
+	return clusters
+}
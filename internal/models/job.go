@@ -1,11 +1,17 @@
 package models
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"strings"
 	"time"
 
 	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/markup"
+	"github.com/Ademayowa/job-board/internal/validation"
 
 	"github.com/google/uuid"
 )
@@ -13,62 +19,277 @@ import (
 // DateFormat is the standard date format used throughout the application
 const DateFormat = time.RFC3339
 
+// ExpirationDays is how long a job stays open after it is published
+const ExpirationDays = 14
+
 type Job struct {
 	ID          string   `json:"id"`
-	Title       string   `json:"title" binding:"required"`
-	Description string   `json:"description" binding:"required"`
-	Location    string   `json:"location" binding:"required"`
-	Salary      float64  `json:"salary" binding:"required"`
-	Duties      []string `json:"duties" binding:"required"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Location    string   `json:"location"`
+	Salary      float64  `json:"salary"`
+	Duties      []string `json:"duties"`
 	Url         string   `json:"url"`
 	CreatedAt   string   `json:"created_at"`
 	Expired     bool     `json:"expired"`
+
+	// Description and Duties are Markdown. The sanitized HTML rendered from
+	// them on save is stored alongside and can't be set directly.
+	DescriptionMarkdown string   `json:"description_markdown"`
+	DescriptionHTML     string   `json:"description_html"`
+	DutiesHTML          []string `json:"duties_html"`
+
+	// Recent jobs by the same owner this one looks like, only set when
+	// the job is created
+	PossibleDuplicates []string `json:"possible_duplicates,omitempty"`
+
+	// Spam scoring of the job when it was posted or last edited. It is
+	// kept out of the API so spammers can't tune their posts against the
+	// rules.
+	SpamScore   float64  `json:"-"`
+	SpamReasons []string `json:"-"`
+	scored      bool
+	held        bool
+
+	// Hidden jobs were taken down after being reported and only their
+	// owner and moderators can see them
+	Hidden bool `json:"hidden"`
+
+	// Publishing workflow, managed through transitions rather than edits
+	Status        string `json:"status"`
+	OwnerID       string `json:"owner_id"`
+	PublishAt     string `json:"publish_at,omitempty"`
+	PublishedAt   string `json:"published_at,omitempty"`
+	UpdatedAt     string `json:"updated_at,omitempty"`
+	ReviewComment string `json:"review_comment,omitempty"`
 }
 
-// IsExpired checks if a job is expired
+// Limits on the size of the editable job fields
+const (
+	MaxTitleLength       = 200
+	MaxDescriptionLength = 20000
+	MaxLocationLength    = 200
+	MaxURLLength         = 2048
+	MaxDuties            = 50
+	MaxDutyLength        = 500
+)
+
+// Validate checks the fields an employer can edit and returns
+// validation.Errors listing every invalid one
+func (job *Job) Validate() error {
+	return validation.Validate(
+		validation.Field("title", job.Title, validation.Required(), validation.MaxLength(MaxTitleLength)),
+		validation.Field("description", job.Description, validation.Required(), validation.MaxLength(MaxDescriptionLength)),
+		validation.Field("location", job.Location, validation.Required(), validation.MaxLength(MaxLocationLength)),
+		validation.Field("salary", job.Salary, validation.NotZero[float64](), validation.Min(0)),
+		validation.Field("duties", job.Duties, validation.NotEmpty[string](), validation.MaxItems[string](MaxDuties)),
+		validation.Each("duties", job.Duties, validation.Required(), validation.MaxLength(MaxDutyLength)),
+		validation.Field("url", job.Url, validation.MaxLength(MaxURLLength), validation.URL()),
+	)
+}
+
+// jobColumns lists the columns of the jobs table in the order scanJob reads them
+const jobColumns = "id, title, description, location, salary, duties, url, created_at, status, owner_id, publish_at, review_comment, description_html, duties_html, spam_score, spam_reasons, hidden, published_at, updated_at"
+
+// publishedAtColumn is when a job was published. Jobs published before the
+// time was recorded count from their creation.
+const publishedAtColumn = "COALESCE(published_at, created_at)"
+
+// IsExpired checks if a job is expired. Jobs that were never published
+// haven't started to expire.
 func (job *Job) IsExpired() bool {
+	if job.ExpiresAt().IsZero() {
+		return false
+	}
 	return job.DaysToExpiration() <= 0
 }
 
+// PublishedTime returns the time a job was first published, or the zero
+// time if it never was
+func (job *Job) PublishedTime() time.Time {
+	publishedAt := job.PublishedAt
+	if publishedAt == "" && (job.Status == StatusPublished || job.Status == StatusClosed) {
+		publishedAt = job.CreatedAt
+	}
+
+	published, err := time.Parse(DateFormat, publishedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return published
+}
+
+// ModifiedTime returns the time a job was last published or edited,
+// whichever is later, or the zero time if neither happened
+func (job *Job) ModifiedTime() time.Time {
+	modified := job.PublishedTime()
+	if updated, err := time.Parse(DateFormat, job.UpdatedAt); err == nil && updated.After(modified) {
+		modified = updated
+	}
+	return modified
+}
+
+// ExpiresAt returns the time a job stops accepting applications, counted
+// from when it was published, or the zero time if it never was
+func (job *Job) ExpiresAt() time.Time {
+	published := job.PublishedTime()
+	if published.IsZero() {
+		return time.Time{}
+	}
+
+	return published.AddDate(0, 0, ExpirationDays)
+}
+
 // DaysToExpiration returns the number of days until job expires
 // Positive: days remaining, Zero: expires today, Negative: days since expiration
 func (job *Job) DaysToExpiration() int {
-	createdAt, err := time.Parse(DateFormat, job.CreatedAt)
-	if err != nil {
+	expirationDate := job.ExpiresAt()
+	if expirationDate.IsZero() {
 		return 0
 	}
 
-	expirationDate := createdAt.AddDate(0, 0, 14) // 14 days after creation
-
 	// Calculate days remaining
 	now := time.Now()
 	duration := expirationDate.Sub(now)
 	return int(duration.Hours() / 24)
 }
 
-// Save job into the database
-func (job *Job) Save() error {
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Save job into the database. duplicates is the policy for near-duplicates,
+// DuplicateWarn or DuplicateReject.
+func (job *Job) Save(ctx context.Context, actor, duplicates string) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := job.create(ctx, tx, actor, duplicates); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// create assigns the job a new ID, writes it and records its creation
+// using the given transaction. Near-duplicates of the owner's recent jobs
+// are flagged or rejected depending on the policy.
+func (job *Job) create(ctx context.Context, tx *sql.Tx, actor, policy string) error {
+	if err := job.checkNew(ctx, tx, actor, policy); err != nil {
+		return err
+	}
+	return job.writeNew(ctx, tx, actor)
+}
+
+// checkNew readies a new job to be written by actor, refusing it if actor
+// may not post it. Near-duplicates are flagged or rejected depending on
+// the policy.
+func (job *Job) checkNew(ctx context.Context, tx *sql.Tx, actor, policy string) error {
+	if actor == "" || actor == AnonymousActor || actor == SystemActor {
+		return ErrOwnerRequired
+	}
+
 	job.ID = uuid.New().String()
+	job.CreatedAt = time.Now().Format(DateFormat)
+
+	// Every job starts as a draft owned by whoever created it, unless it
+	// looks like spam and has to be moderated first
+	job.Status = StatusDraft
+	if job.held {
+		job.Status = StatusHeld
+	}
+	job.Hidden = false
+	job.OwnerID = actor
+	job.PublishAt = ""
+	job.PublishedAt = ""
+	job.UpdatedAt = ""
+	job.ReviewComment = ""
+
+	banned, err := isBanned(ctx, tx, actor)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return ErrEmployerBanned
+	}
+
+	duplicates, err := findDuplicates(ctx, tx, *job)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 && policy == DuplicateReject {
+		return &DuplicateError{JobIDs: duplicates}
+	}
+	job.PossibleDuplicates = duplicates
+
+	return nil
+}
+
+// writeNew writes a job readied by checkNew and records its creation
+func (job *Job) writeNew(ctx context.Context, tx *sql.Tx, actor string) error {
+	if err := job.insert(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := recordJobEvent(ctx, tx, actor, ActionCreate, nil, job); err != nil {
+		return err
+	}
+
+	return enqueueChange(ctx, tx, nil, job)
+}
+
+// FlagSpam records how suspicious a new job or an edit looked to the spam
+// filter. A held job is kept out of review until a moderator releases it.
+func (job *Job) FlagSpam(score float64, reasons []string, hold bool) {
+	job.SpamScore = score
+	job.SpamReasons = reasons
+	job.scored = true
+	job.held = hold
+}
+
+// renderMarkup converts the Markdown description and duties to sanitized HTML
+func (job *Job) renderMarkup() {
+	job.DescriptionMarkdown = job.Description
+	job.DescriptionHTML = markup.Render(job.Description)
+
+	job.DutiesHTML = make([]string, len(job.Duties))
+	for i, duty := range job.Duties {
+		job.DutiesHTML[i] = markup.RenderInline(duty)
+	}
+}
+
+// insert writes the job row as it is, along with its rendered HTML
+func (job *Job) insert(ctx context.Context, exec execer) error {
+	job.renderMarkup()
 
 	dutiesJSON, err := json.Marshal(job.Duties)
 	if err != nil {
 		return err
 	}
 
-	query := `
-		INSERT INTO jobs(id, title, description, location, salary, duties, url, created_at)
-		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
-	`
+	dutiesHTMLJSON, err := json.Marshal(job.DutiesHTML)
+	if err != nil {
+		return err
+	}
 
-	sqlStmt, err := db.DB.Prepare(query)
+	spamReasonsJSON, err := json.Marshal(job.SpamReasons)
 	if err != nil {
 		return err
 	}
-	defer sqlStmt.Close()
 
-	job.CreatedAt = time.Now().Format(DateFormat)
+	query := `
+		INSERT INTO jobs(` + jobColumns + `, fingerprint)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
 
-	_, err = sqlStmt.Exec(
+	_, err = exec.ExecContext(ctx, query,
 		job.ID,
 		job.Title,
 		job.Description,
@@ -77,15 +298,146 @@ func (job *Job) Save() error {
 		string(dutiesJSON),
 		job.Url,
 		job.CreatedAt,
+		job.Status,
+		job.OwnerID,
+		nullString(job.PublishAt),
+		job.ReviewComment,
+		job.DescriptionHTML,
+		string(dutiesHTMLJSON),
+		job.SpamScore,
+		string(spamReasonsJSON),
+		job.Hidden,
+		nullString(job.PublishedAt),
+		nullString(job.UpdatedAt),
+		int64(job.fingerprint()),
 	)
 
 	return err
 }
 
-// Get all jobs (with optional filtering by job title)
-func GetAllJobs(filterTitle string, page, limit int) ([]Job, int, error) {
-	query := "SELECT * FROM jobs WHERE 1=1"
-	args := []interface{}{}
+// ImportJobs saves many jobs in a single transaction. Near-duplicates are
+// handled as in Save, comparing each job only with those saved before the
+// import, so a file may repeat a job.
+//
+// Unless partial is set, either every job is written or none of them are.
+// With partial set, jobs that can't be saved, such as rejected
+// near-duplicates, are skipped and the rest are written. The returned
+// slice holds why each job was skipped, nil for the jobs written.
+func ImportJobs(ctx context.Context, jobs []Job, actor, duplicates string, partial bool) ([]error, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Every job is checked before any is written, so the jobs of one file
+	// aren't taken for duplicates of each other
+	skipped := make([]error, len(jobs))
+	for i := range jobs {
+		err := jobs[i].checkNew(ctx, tx, actor, duplicates)
+		if err != nil && (!partial || !isJobRejection(err)) {
+			return nil, err
+		}
+		skipped[i] = err
+	}
+
+	for i := range jobs {
+		if skipped[i] != nil {
+			continue
+		}
+		if err := jobs[i].writeNew(ctx, tx, actor); err != nil {
+			return nil, err
+		}
+	}
+
+	return skipped, tx.Commit()
+}
+
+// isJobRejection reports whether err refuses a single new job rather than
+// the whole request failing
+func isJobRejection(err error) bool {
+	return errors.Is(err, ErrOwnerRequired) || errors.Is(err, ErrEmployerBanned) || errors.Is(err, ErrDuplicateJob)
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob reads a job from a row that selected jobColumns
+func scanJob(row rowScanner) (Job, error) {
+	var job Job
+	var dutiesJSON string
+	var publishAt, publishedAt, updatedAt sql.NullString
+	var descriptionHTML, dutiesHTMLJSON sql.NullString
+	var spamReasonsJSON string
+
+	err := row.Scan(
+		&job.ID,
+		&job.Title,
+		&job.Description,
+		&job.Location,
+		&job.Salary,
+		&dutiesJSON,
+		&job.Url,
+		&job.CreatedAt,
+		&job.Status,
+		&job.OwnerID,
+		&publishAt,
+		&job.ReviewComment,
+		&descriptionHTML,
+		&dutiesHTMLJSON,
+		&job.SpamScore,
+		&spamReasonsJSON,
+		&job.Hidden,
+		&publishedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return job, err
+	}
+
+	if err := json.Unmarshal([]byte(spamReasonsJSON), &job.SpamReasons); err != nil {
+		return job, err
+	}
+	job.PublishAt = publishAt.String
+	job.PublishedAt = publishedAt.String
+	job.UpdatedAt = updatedAt.String
+
+	// Convert Duties field from JSON to []string
+	if err := json.Unmarshal([]byte(dutiesJSON), &job.Duties); err != nil {
+		return job, err
+	}
+
+	// Jobs saved before Markdown support have no HTML stored yet
+	if descriptionHTML.Valid && dutiesHTMLJSON.Valid {
+		job.DescriptionMarkdown = job.Description
+		job.DescriptionHTML = descriptionHTML.String
+		if err := json.Unmarshal([]byte(dutiesHTMLJSON.String), &job.DutiesHTML); err != nil {
+			return job, err
+		}
+	} else {
+		job.renderMarkup()
+	}
+
+	// Check if job is expired
+	job.Expired = job.IsExpired()
+
+	return job, nil
+}
+
+// nullString stores an empty string as NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// filterJobs builds the query that selects public jobs matching the filters
+func filterJobs(filterTitle string) (string, []interface{}) {
+	query := "SELECT " + jobColumns + " FROM jobs WHERE status = ? AND hidden = 0"
+	args := []interface{}{StatusPublished}
 
 	// Filter jobs by the title
 	if strings.TrimSpace(filterTitle) != "" {
@@ -93,11 +445,21 @@ func GetAllJobs(filterTitle string, page, limit int) ([]Job, int, error) {
 		args = append(args, "%"+strings.ToLower(filterTitle)+"%")
 	}
 
+	return query, args
+}
+
+// Get all jobs (with optional filtering by job title)
+func GetAllJobs(ctx context.Context, filterTitle string, page, limit int) ([]Job, int, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query, args := filterJobs(filterTitle)
+
 	// Count total jobs that matches the filter from the database
 	countQuery := "SELECT COUNT(*) FROM (" + query + ") AS count_query"
 
 	var total int
-	err := db.DB.QueryRow(countQuery, args...).Scan(&total)
+	err := db.DB.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -108,7 +470,7 @@ func GetAllJobs(filterTitle string, page, limit int) ([]Job, int, error) {
 	args = append(args, limit, offset)
 
 	// Fetch paginated jobs
-	rows, err := db.DB.Query(query, args...)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -117,155 +479,324 @@ func GetAllJobs(filterTitle string, page, limit int) ([]Job, int, error) {
 	var jobs []Job
 
 	for rows.Next() {
-		var job Job
-		var dutiesJSON string
-
-		err := rows.Scan(
-			&job.ID,
-			&job.Title,
-			&job.Description,
-			&job.Location,
-			&job.Salary,
-			&dutiesJSON,
-			&job.Url,
-			&job.CreatedAt,
-		)
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, 0, err
 		}
 
-		// Convert Duties field to []string
-		if err := json.Unmarshal([]byte(dutiesJSON), &job.Duties); err != nil {
-			return nil, 0, err
-		}
-
-		// Check if job is expired
-		job.Expired = job.IsExpired()
-
 		jobs = append(jobs, job)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
 	return jobs, total, nil
 }
 
 // Get a job by ID
-func GetJobByID(id string) (Job, error) {
-	var job Job
-	var dutiesJSON string
+func GetJobByID(ctx context.Context, id string) (Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
 
-	query := "SELECT * FROM jobs WHERE id =?"
-	row := db.DB.QueryRow(query, id)
+	query := "SELECT " + jobColumns + " FROM jobs WHERE id = ?"
+	row := db.DB.QueryRowContext(ctx, query, id)
 
-	err := row.Scan(
-		&job.ID,
-		&job.Title,
-		&job.Description,
-		&job.Location,
-		&job.Salary, &dutiesJSON,
-		&job.Url,
-		&job.CreatedAt,
-	)
+	return scanJob(row)
+}
+
+// Delete a job
+func (job Job) Delete(ctx context.Context, actor string) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return job, err
+		return err
 	}
+	defer tx.Rollback()
 
-	// Convert Duties field from JSON to []string
-	err = json.Unmarshal([]byte(dutiesJSON), &job.Duties)
+	if err := job.delete(ctx, tx, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// delete removes the job and records its removal using the given transaction
+func (job Job) delete(ctx context.Context, exec execer, actor string) error {
+	query := "DELETE FROM jobs WHERE id = ?"
+	_, err := exec.ExecContext(ctx, query, job.ID)
 	if err != nil {
-		return job, err
+		return err
 	}
 
-	// Check if job is expired
-	job.Expired = job.IsExpired()
+	if err := recordJobEvent(ctx, exec, actor, ActionDelete, &job, nil); err != nil {
+		return err
+	}
 
-	return job, nil
+	return enqueueChange(ctx, exec, &job, nil)
 }
 
-// Delete a job
-func (job Job) Delete() error {
-	query := "DELETE FROM jobs WHERE id = ?"
-	stmt, err := db.DB.Prepare(query)
+// Update a job by ID on behalf of actor acting in role
+func UpdateJobByID(ctx context.Context, id string, updatedJob Job, dutiesJSON string, actor, role string) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	// Nothing to update or announce if no job has this ID
+	before, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(job.ID)
+	if !CanEdit(before, actor, role) {
+		return ErrForbidden
+	}
 
-	return err
+	if _, err := applyUpdate(ctx, tx, before, updatedJob, dutiesJSON, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// Update a job by ID
-func UpdateJobByID(id string, updatedJob Job, dutiesJSON string) error {
+// applyUpdate overwrites the editable fields of a job and records the
+// change: the previous version is kept as a revision, the audit log gets
+// a diff and subscribers are notified. An edit the spam filter flagged
+// holds the job for moderation, and a job that was approved otherwise
+// goes back to review, as what was approved is no longer what is shown.
+func applyUpdate(ctx context.Context, tx *sql.Tx, before Job, updatedJob Job, dutiesJSON string, actor string) (Job, error) {
+	updatedJob.renderMarkup()
+
+	dutiesHTMLJSON, err := json.Marshal(updatedJob.DutiesHTML)
+	if err != nil {
+		return Job{}, err
+	}
+
 	query := `
 		UPDATE jobs
-		SET title = ?, description = ?, location = ?, salary = ?, duties = ?, url = ?
+		SET title = ?, description = ?, location = ?, salary = ?, duties = ?, url = ?,
+			description_html = ?, duties_html = ?, fingerprint = ?
 		WHERE id = ?
 	`
-	_, err := db.DB.Exec(query,
+	_, err = tx.ExecContext(ctx, query,
 		updatedJob.Title,
 		updatedJob.Description,
 		updatedJob.Location,
 		updatedJob.Salary,
 		dutiesJSON,
 		updatedJob.Url,
-		id,
+		updatedJob.DescriptionHTML,
+		string(dutiesHTMLJSON),
+		int64(updatedJob.fingerprint()),
+		before.ID,
 	)
+	if err != nil {
+		return Job{}, err
+	}
 
-	return err
+	job, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", before.ID))
+	if err != nil {
+		return Job{}, err
+	}
+
+	// An update that changed nothing leaves no trace
+	if len(diffJobs(&before, &job)) == 0 {
+		return job, nil
+	}
+
+	job.UpdatedAt = time.Now().UTC().Format(DateFormat)
+	if _, err := tx.ExecContext(ctx, "UPDATE jobs SET updated_at = ? WHERE id = ?", job.UpdatedAt, before.ID); err != nil {
+		return Job{}, err
+	}
+
+	if updatedJob.scored {
+		spamReasonsJSON, err := json.Marshal(updatedJob.SpamReasons)
+		if err != nil {
+			return Job{}, err
+		}
+
+		query := "UPDATE jobs SET spam_score = ?, spam_reasons = ? WHERE id = ?"
+		if _, err := tx.ExecContext(ctx, query, updatedJob.SpamScore, string(spamReasonsJSON), before.ID); err != nil {
+			return Job{}, err
+		}
+		job.SpamScore = updatedJob.SpamScore
+		job.SpamReasons = updatedJob.SpamReasons
+	}
+
+	status := job.Status
+	switch {
+	case updatedJob.held:
+		status = StatusHeld
+	case job.Status == StatusPublished || job.Status == StatusScheduled:
+		status = StatusPendingReview
+	}
+	if status != job.Status {
+		query := "UPDATE jobs SET status = ?, publish_at = NULL, review_comment = '' WHERE id = ?"
+		if _, err := tx.ExecContext(ctx, query, status, before.ID); err != nil {
+			return Job{}, err
+		}
+		job.Status = status
+		job.PublishAt = ""
+		job.ReviewComment = ""
+	}
+
+	if err := saveRevision(ctx, tx, before, actor); err != nil {
+		return Job{}, err
+	}
+
+	if err := recordJobEvent(ctx, tx, actor, ActionUpdate, &before, &job); err != nil {
+		return Job{}, err
+	}
+
+	if err := enqueueChange(ctx, tx, &before, &job); err != nil {
+		return Job{}, err
+	}
+
+	return job, nil
 }
 
-// Get jobs sorted by most recent
-func GetJobsSortedByRecent(limit int) ([]Job, error) {
-	query := "SELECT * FROM jobs ORDER BY created_at DESC LIMIT ?"
+// RestoreJob brings back a deleted job exactly as it was when it was
+// deleted, using the snapshot kept in its audit history. Only its owner
+// and admins may restore it.
+func RestoreJob(ctx context.Context, id string, actor, role string) (Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
 
-	rows, err := db.DB.Query(query, limit)
+	tx, err := db.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return Job{}, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var jobs []Job
+	job, err := deletedJob(ctx, tx, id)
+	if err != nil {
+		return Job{}, err
+	}
 
-	for rows.Next() {
-		var job Job
-		var dutiesJSON string
-
-		err := rows.Scan(
-			&job.ID,
-			&job.Title,
-			&job.Description,
-			&job.Location,
-			&job.Salary,
-			&dutiesJSON,
-			&job.Url,
-			&job.CreatedAt,
-		)
+	if !CanEdit(job, actor, role) {
+		return Job{}, ErrForbidden
+	}
+
+	// Jobs a moderator took down stay down, as do the jobs of banned
+	// employers
+	removed, err := removedByModerator(ctx, tx, id)
+	if err != nil {
+		return Job{}, err
+	}
+	if removed {
+		return Job{}, ErrRemovedByModerator
+	}
+
+	banned, err := isBanned(ctx, tx, job.OwnerID)
+	if err != nil {
+		return Job{}, err
+	}
+	if banned {
+		return Job{}, ErrEmployerBanned
+	}
+
+	if err := job.insert(ctx, tx); err != nil {
+		return Job{}, err
+	}
+
+	if err := recordJobEvent(ctx, tx, actor, ActionRestore, nil, &job); err != nil {
+		return Job{}, err
+	}
+
+	// To subscribers a restored job is a new job
+	if err := enqueueChange(ctx, tx, nil, &job); err != nil {
+		return Job{}, err
+	}
+
+	job.Expired = job.IsExpired()
+
+	return job, tx.Commit()
+}
 
+// EachJob streams every job matching the filters to fn, one row at a time,
+// without loading the full result set into memory. Iteration stops at the
+// first error returned by fn. A large export takes longer than the query
+// timeout allows, so the export timeout bounds it instead.
+func EachJob(ctx context.Context, filterTitle string, fn func(Job) error) error {
+	ctx, cancel := db.WithExportTimeout(ctx)
+	defer cancel()
+
+	query, args := filterJobs(filterTitle)
+	query += " ORDER BY created_at DESC"
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		job, err := scanJob(rows)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		err = json.Unmarshal([]byte(dutiesJSON), &job.Duties)
+		if err := fn(job); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Get jobs sorted by most recent (with optional filtering by job title)
+func GetJobsSortedByRecent(ctx context.Context, filterTitle string, limit int) ([]Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query, args := filterJobs(filterTitle)
+	query += " ORDER BY created_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+
+	for rows.Next() {
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		// Check if job is expired
-		job.Expired = job.IsExpired()
-
 		jobs = append(jobs, job)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return jobs, nil
 }
 
 // Get jobs sorted by highest salary
-func GetJobsSortedBySalary(limit int) ([]Job, error) {
-	query := "SELECT * FROM jobs ORDER BY salary DESC LIMIT ?"
+func GetJobsSortedBySalary(ctx context.Context, limit int) ([]Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query, args := filterJobs("")
+	query += " ORDER BY salary DESC LIMIT ?"
+	args = append(args, limit)
 
-	rows, err := db.DB.Query(query, limit)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -274,34 +805,35 @@ func GetJobsSortedBySalary(limit int) ([]Job, error) {
 	var jobs []Job
 
 	for rows.Next() {
-		var job Job
-		var dutiesJSON string
-
-		err := rows.Scan(
-			&job.ID,
-			&job.Title,
-			&job.Description,
-			&job.Location,
-			&job.Salary,
-			&dutiesJSON,
-			&job.Url,
-			&job.CreatedAt,
-		)
-
+		job, err := scanJob(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		err = json.Unmarshal([]byte(dutiesJSON), &job.Duties)
-		if err != nil {
-			return nil, err
-		}
-
-		// Check if job is expired
-		job.Expired = job.IsExpired()
-
 		jobs = append(jobs, job)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return jobs, nil
 }
+
+// CountPublicJobs counts the published jobs that are still open and those
+// that have passed their expiration date
+func CountPublicJobs(ctx context.Context) (active, expired int, err error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT
+			COALESCE(SUM(julianday(` + publishedAtColumn + `) > julianday('now', ?)), 0),
+			COALESCE(SUM(julianday(` + publishedAtColumn + `) <= julianday('now', ?)), 0)
+		FROM jobs WHERE status = ? AND hidden = 0
+	`
+	cutoff := "-" + strconv.Itoa(ExpirationDays) + " days"
+
+	err = db.DB.QueryRowContext(ctx, query, cutoff, cutoff, StatusPublished).Scan(&active, &expired)
+	return active, expired, err
+}
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// Orders jobs can be listed in. Ties are broken by ID so every job has a
+// stable position to resume from.
+const (
+	JobSortRecent = "recent"
+	JobSortSalary = "salary"
+)
+
+// jobSortColumns maps each order to the column jobs are sorted on, descending
+var jobSortColumns = map[string]string{
+	JobSortRecent: "created_at",
+	JobSortSalary: "salary",
+}
+
+// ErrInvalidCursor is returned when a cursor wasn't issued for the order asked for
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// JobFilter selects a slice of the public jobs. It is comparable, so
+// callers can group requests that share a filter.
+type JobFilter struct {
+	// Title only keeps jobs whose title contains it
+	Title string
+	// Sort is JobSortRecent or JobSortSalary
+	Sort string
+	// After is the cursor of the job to list from, exclusive
+	After string
+	// First is how many jobs to list
+	First int
+}
+
+// JobPage is a slice of a list of jobs
+type JobPage struct {
+	Jobs []Job
+	// Cursors[i] marks the position of Jobs[i], to list the jobs after it
+	Cursors []string
+	// HasNextPage reports whether more jobs follow the last one
+	HasNextPage bool
+	// Total counts the jobs in the list across every page
+	Total int
+}
+
+// ListJobs returns the public jobs matching filter, in its order
+func ListJobs(ctx context.Context, filter JobFilter) (JobPage, error) {
+	pages, err := listJobs(ctx, "", nil, filter)
+	return pages[""], err
+}
+
+// ListJobsByOwner lists the public jobs of each owner with one query.
+// Owners without any are left out of the result.
+func ListJobsByOwner(ctx context.Context, owners []string, filter JobFilter) (map[string]JobPage, error) {
+	return listJobs(ctx, "owner_id", owners, filter)
+}
+
+// ListJobsByLocation lists the public jobs in each location with one
+// query. Locations without any are left out of the result.
+func ListJobsByLocation(ctx context.Context, locations []string, filter JobFilter) (map[string]JobPage, error) {
+	return listJobs(ctx, "location", locations, filter)
+}
+
+// listJobs pages through the public jobs matching filter separately for
+// each value of column, or through all of them when column is empty
+func listJobs(ctx context.Context, column string, values []string, filter JobFilter) (map[string]JobPage, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	sortColumn, ok := jobSortColumns[filter.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown job sort %q", filter.Sort)
+	}
+
+	query, args := filterJobs(filter.Title)
+	group := "''"
+	if column != "" {
+		if len(values) == 0 {
+			return map[string]JobPage{}, nil
+		}
+		group = column
+		query += " AND " + column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")"
+		for _, value := range values {
+			args = append(args, value)
+		}
+	}
+
+	// Count the whole list before skipping to the cursor
+	totals, err := countJobGroups(ctx, "SELECT "+group+", COUNT(*) FROM ("+query+") GROUP BY 1", args)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.After != "" {
+		key, id, err := decodeJobCursor(filter.Sort, filter.After)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND (" + sortColumn + " < ? OR (" + sortColumn + " = ? AND id < ?))"
+		args = append(args, key, key, id)
+	}
+
+	// Number the jobs of each group in order and keep one more than asked
+	// for, to tell whether another page follows
+	query = "SELECT " + jobColumns + " FROM (" +
+		"SELECT *, ROW_NUMBER() OVER (PARTITION BY " + group + " ORDER BY " + sortColumn + " DESC, id DESC) AS position FROM (" + query + ")" +
+		") WHERE position <= ? ORDER BY " + group + ", position"
+	args = append(args, filter.First+1)
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := map[string]JobPage{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		key := jobGroup(column, job)
+		page := pages[key]
+		if len(page.Jobs) == filter.First {
+			page.HasNextPage = true
+		} else {
+			page.Jobs = append(page.Jobs, job)
+			page.Cursors = append(page.Cursors, encodeJobCursor(filter.Sort, job))
+		}
+		pages[key] = page
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for key, total := range totals {
+		page := pages[key]
+		page.Total = total
+		pages[key] = page
+	}
+
+	return pages, nil
+}
+
+// countJobGroups runs a query selecting a group and its number of jobs
+func countJobGroups(ctx context.Context, query string, args []interface{}) (map[string]int, error) {
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[string]int{}
+	for rows.Next() {
+		var group string
+		var total int
+		if err := rows.Scan(&group, &total); err != nil {
+			return nil, err
+		}
+		totals[group] = total
+	}
+
+	return totals, rows.Err()
+}
+
+// jobGroup returns the value of column for job
+func jobGroup(column string, job Job) string {
+	switch column {
+	case "owner_id":
+		return job.OwnerID
+	case "location":
+		return job.Location
+	default:
+		return ""
+	}
+}
+
+// encodeJobCursor marks the position of job in a list sorted by sort.
+// Cursors are opaque to clients.
+func encodeJobCursor(sort string, job Job) string {
+	var key interface{} = job.CreatedAt
+	if sort == JobSortSalary {
+		key = job.Salary
+	}
+
+	encoded, _ := json.Marshal([]interface{}{sort, key, job.ID})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeJobCursor returns the sort key and ID a cursor of a list sorted
+// by sort marks
+func decodeJobCursor(sort, cursor string) (interface{}, string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", ErrInvalidCursor
+	}
+
+	var parts []interface{}
+	if err := json.Unmarshal(decoded, &parts); err != nil || len(parts) != 3 || parts[0] != sort {
+		return nil, "", ErrInvalidCursor
+	}
+
+	id, ok := parts[2].(string)
+	if !ok {
+		return nil, "", ErrInvalidCursor
+	}
+
+	switch key := parts[1].(type) {
+	case float64:
+		if sort == JobSortSalary {
+			return key, id, nil
+		}
+	case string:
+		if sort == JobSortRecent {
+			return key, id, nil
+		}
+	}
+	return nil, "", ErrInvalidCursor
+}
@@ -0,0 +1,448 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Reasons a job can be reported for
+const (
+	ReasonFraud          = "fraud"
+	ReasonOffensive      = "offensive"
+	ReasonSpam           = "spam"
+	ReasonMisleading     = "misleading"
+	ReasonDiscriminatory = "discriminatory"
+	ReasonOther          = "other"
+)
+
+// ReportReasons lists every valid report reason
+var ReportReasons = []string{ReasonFraud, ReasonOffensive, ReasonSpam, ReasonMisleading, ReasonDiscriminatory, ReasonOther}
+
+// ReportHideThreshold is the number of open reports that hides a job
+// until a moderator looks at it
+const ReportHideThreshold = 3
+
+// Decisions recorded in the moderation log
+const (
+	DecisionAutoHide    = "auto_hide"
+	DecisionApprove     = "approve"
+	DecisionRemove      = "remove"
+	DecisionBanEmployer = "ban_employer"
+)
+
+// Actions recorded in the audit log when a job is hidden or shown again
+const (
+	ActionHide   = "hide"
+	ActionUnhide = "unhide"
+)
+
+var (
+	// ErrUnknownReason is returned for a report reason not in ReportReasons
+	ErrUnknownReason = errors.New("unknown report reason")
+	// ErrAlreadyReported is returned when a user reports the same job twice
+	ErrAlreadyReported = errors.New("job already reported by this user")
+	// ErrEmployerBanned is returned when a banned employer posts a job
+	ErrEmployerBanned = errors.New("employer is banned from posting jobs")
+	// ErrReporterRequired is returned when a job is reported anonymously
+	ErrReporterRequired = errors.New("jobs can only be reported by an authenticated user")
+	// ErrNoOwner is returned when banning the owner of a job nobody owns
+	ErrNoOwner = errors.New("job has no owner to ban")
+	// ErrRemovedByModerator is returned when restoring a job a moderator
+	// removed
+	ErrRemovedByModerator = errors.New("job was removed by a moderator")
+)
+
+// Report is a complaint about a job
+type Report struct {
+	ID         string `json:"id"`
+	JobID      string `json:"job_id"`
+	Reporter   string `json:"reporter"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details"`
+	CreatedAt  string `json:"created_at"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+}
+
+// ModerationDecision is an entry in the moderation log
+type ModerationDecision struct {
+	ID        int64  `json:"id"`
+	JobID     string `json:"job_id"`
+	OwnerID   string `json:"owner_id"`
+	Moderator string `json:"moderator"`
+	Action    string `json:"action"`
+	Comment   string `json:"comment"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ModerationItem is a job waiting for a moderator along with why
+type ModerationItem struct {
+	Job         Job      `json:"job"`
+	SpamScore   float64  `json:"spam_score"`
+	SpamReasons []string `json:"spam_reasons"`
+	Reports     []Report `json:"reports"`
+}
+
+// ReportJob files a report against a job. Once a job has
+// ReportHideThreshold open reports it is hidden from the public.
+func ReportJob(ctx context.Context, jobID, reporter, reason, details string) (Report, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	if !contains(ReportReasons, reason) {
+		return Report{}, ErrUnknownReason
+	}
+
+	// Reports are counted once per user, so every anonymous reporter
+	// would share one
+	if reporter == "" || reporter == AnonymousActor || reporter == SystemActor {
+		return Report{}, ErrReporterRequired
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Report{}, err
+	}
+	defer tx.Rollback()
+
+	job, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", jobID))
+	if err != nil {
+		return Report{}, err
+	}
+
+	var reported int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM job_reports WHERE job_id = ? AND reporter = ? AND resolved_at IS NULL", jobID, reporter).Scan(&reported)
+	if err != nil {
+		return Report{}, err
+	}
+	if reported > 0 {
+		return Report{}, ErrAlreadyReported
+	}
+
+	report := Report{
+		ID:        uuid.New().String(),
+		JobID:     jobID,
+		Reporter:  reporter,
+		Reason:    reason,
+		Details:   details,
+		CreatedAt: time.Now().UTC().Format(DateFormat),
+	}
+
+	query := "INSERT INTO job_reports(id, job_id, reporter, reason, details, created_at) VALUES(?, ?, ?, ?, ?, ?)"
+	_, err = tx.ExecContext(ctx, query, report.ID, report.JobID, report.Reporter, report.Reason, report.Details, report.CreatedAt)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var open int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM job_reports WHERE job_id = ? AND resolved_at IS NULL", jobID).Scan(&open)
+	if err != nil {
+		return Report{}, err
+	}
+
+	if open >= ReportHideThreshold && !job.Hidden {
+		if _, err := setHidden(ctx, tx, job, true, SystemActor); err != nil {
+			return Report{}, err
+		}
+		if err := recordDecision(ctx, tx, job, SystemActor, DecisionAutoHide, ""); err != nil {
+			return Report{}, err
+		}
+	}
+
+	return report, tx.Commit()
+}
+
+// setHidden hides or shows a job and records the change
+func setHidden(ctx context.Context, tx *sql.Tx, before Job, hidden bool, actor string) (Job, error) {
+	if _, err := tx.ExecContext(ctx, "UPDATE jobs SET hidden = ? WHERE id = ?", hidden, before.ID); err != nil {
+		return Job{}, err
+	}
+
+	job, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", before.ID))
+	if err != nil {
+		return Job{}, err
+	}
+
+	action := ActionUnhide
+	if hidden {
+		action = ActionHide
+	}
+	if err := recordJobEvent(ctx, tx, actor, action, &before, &job); err != nil {
+		return Job{}, err
+	}
+
+	if err := enqueueChange(ctx, tx, &before, &job); err != nil {
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+// recordDecision appends an entry to the moderation log
+func recordDecision(ctx context.Context, exec execer, job Job, moderator, action, comment string) error {
+	query := `
+		INSERT INTO moderation_decisions(job_id, owner_id, moderator, action, comment, created_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+	`
+	_, err := exec.ExecContext(ctx, query, job.ID, job.OwnerID, moderator, action, comment, time.Now().UTC().Format(DateFormat))
+	return err
+}
+
+// resolveReports closes every open report against a job
+func resolveReports(ctx context.Context, exec execer, jobID string) error {
+	query := "UPDATE job_reports SET resolved_at = ? WHERE job_id = ? AND resolved_at IS NULL"
+	_, err := exec.ExecContext(ctx, query, time.Now().UTC().Format(DateFormat), jobID)
+	return err
+}
+
+// isBanned reports whether an employer has been banned
+func isBanned(ctx context.Context, tx *sql.Tx, ownerID string) (bool, error) {
+	var banned int
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM banned_employers WHERE owner_id = ?", ownerID).Scan(&banned)
+	return banned > 0, err
+}
+
+// removedByModerator reports whether a moderator removed the job, on its
+// own or by banning its owner
+func removedByModerator(ctx context.Context, tx *sql.Tx, jobID string) (bool, error) {
+	var removed int
+	query := "SELECT COUNT(*) FROM moderation_decisions WHERE job_id = ? AND action IN (?, ?)"
+	err := tx.QueryRowContext(ctx, query, jobID, DecisionRemove, DecisionBanEmployer).Scan(&removed)
+	return removed > 0, err
+}
+
+// GetModerationQueue returns the jobs waiting for a moderator: jobs held
+// as spam, hidden jobs and jobs with open reports, most reported first
+func GetModerationQueue(ctx context.Context) ([]ModerationItem, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + jobColumns + ` FROM jobs
+		WHERE status = ? OR hidden = 1
+		OR id IN (SELECT job_id FROM job_reports WHERE resolved_at IS NULL)
+		ORDER BY (SELECT COUNT(*) FROM job_reports WHERE job_id = jobs.id AND resolved_at IS NULL) DESC, created_at
+	`
+	rows, err := db.DB.QueryContext(ctx, query, StatusHeld)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ModerationItem{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, ModerationItem{Job: job, SpamScore: job.SpamScore, SpamReasons: job.SpamReasons, Reports: []Report{}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reports, err := openReports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if jobReports, ok := reports[items[i].Job.ID]; ok {
+			items[i].Reports = jobReports
+		}
+	}
+
+	return items, nil
+}
+
+// openReports returns the unresolved reports keyed by job ID
+func openReports(ctx context.Context) (map[string][]Report, error) {
+	query := `
+		SELECT id, job_id, reporter, reason, details, created_at FROM job_reports
+		WHERE resolved_at IS NULL ORDER BY created_at
+	`
+	rows, err := db.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := map[string][]Report{}
+	for rows.Next() {
+		var report Report
+		err := rows.Scan(&report.ID, &report.JobID, &report.Reporter, &report.Reason, &report.Details, &report.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reports[report.JobID] = append(reports[report.JobID], report)
+	}
+
+	return reports, rows.Err()
+}
+
+// moderate runs a moderation decision on a job in a single transaction
+func moderate(ctx context.Context, jobID string, apply func(tx *sql.Tx, job Job) (Job, error)) (Job, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, err
+	}
+	defer tx.Rollback()
+
+	job, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", jobID))
+	if err != nil {
+		return Job{}, err
+	}
+
+	job, err = apply(tx, job)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return job, tx.Commit()
+}
+
+// ApproveModeratedJob clears a job: its reports are resolved, it is shown
+// again and, if it was held as spam, it goes back to being a draft
+func ApproveModeratedJob(ctx context.Context, jobID, moderator, comment string) (Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	return moderate(ctx, jobID, func(tx *sql.Tx, job Job) (Job, error) {
+		var err error
+		if job.Status == StatusHeld {
+			released := job
+			released.Status = StatusDraft
+			if job, err = applyTransition(ctx, tx, job, released, ActionRelease, moderator); err != nil {
+				return Job{}, err
+			}
+		}
+
+		if job.Hidden {
+			if job, err = setHidden(ctx, tx, job, false, moderator); err != nil {
+				return Job{}, err
+			}
+		}
+
+		if err := resolveReports(ctx, tx, job.ID); err != nil {
+			return Job{}, err
+		}
+
+		return job, recordDecision(ctx, tx, job, moderator, DecisionApprove, comment)
+	})
+}
+
+// RemoveModeratedJob deletes a job that breaks the rules
+func RemoveModeratedJob(ctx context.Context, jobID, moderator, comment string) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	_, err := moderate(ctx, jobID, func(tx *sql.Tx, job Job) (Job, error) {
+		if err := removeJob(ctx, tx, job, moderator, DecisionRemove, comment); err != nil {
+			return Job{}, err
+		}
+		return job, nil
+	})
+	return err
+}
+
+// BanEmployer deletes a job, stops its owner from posting again and hides
+// the rest of the owner's jobs. Jobs left without an owner can only be
+// removed.
+func BanEmployer(ctx context.Context, jobID, moderator, comment string) (string, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	job, err := moderate(ctx, jobID, func(tx *sql.Tx, job Job) (Job, error) {
+		if job.OwnerID == "" || job.OwnerID == AnonymousActor || job.OwnerID == SystemActor {
+			return Job{}, ErrNoOwner
+		}
+
+		query := "INSERT OR IGNORE INTO banned_employers(owner_id, banned_by, reason, created_at) VALUES(?, ?, ?, ?)"
+		_, err := tx.ExecContext(ctx, query, job.OwnerID, moderator, comment, time.Now().UTC().Format(DateFormat))
+		if err != nil {
+			return Job{}, err
+		}
+
+		if err := removeJob(ctx, tx, job, moderator, DecisionBanEmployer, comment); err != nil {
+			return Job{}, err
+		}
+
+		rows, err := tx.QueryContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE owner_id = ? AND hidden = 0", job.OwnerID)
+		if err != nil {
+			return Job{}, err
+		}
+		var others []Job
+		for rows.Next() {
+			other, err := scanJob(rows)
+			if err != nil {
+				rows.Close()
+				return Job{}, err
+			}
+			others = append(others, other)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return Job{}, err
+		}
+
+		for _, other := range others {
+			if _, err := setHidden(ctx, tx, other, true, moderator); err != nil {
+				return Job{}, err
+			}
+		}
+
+		return job, nil
+	})
+
+	return job.OwnerID, err
+}
+
+// removeJob deletes a job on a moderator's decision
+func removeJob(ctx context.Context, tx *sql.Tx, job Job, moderator, decision, comment string) error {
+	if err := job.delete(ctx, tx, moderator); err != nil {
+		return err
+	}
+
+	if err := resolveReports(ctx, tx, job.ID); err != nil {
+		return err
+	}
+
+	return recordDecision(ctx, tx, job, moderator, decision, comment)
+}
+
+// GetModerationDecisions returns the moderation log, newest first,
+// optionally for a single job
+func GetModerationDecisions(ctx context.Context, jobID string) ([]ModerationDecision, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT id, job_id, owner_id, moderator, action, comment, created_at FROM moderation_decisions"
+	args := []interface{}{}
+	if jobID != "" {
+		query += " WHERE job_id = ?"
+		args = append(args, jobID)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	decisions := []ModerationDecision{}
+	for rows.Next() {
+		var decision ModerationDecision
+		err := rows.Scan(&decision.ID, &decision.JobID, &decision.OwnerID, &decision.Moderator, &decision.Action, &decision.Comment, &decision.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, decision)
+	}
+
+	return decisions, rows.Err()
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Job lifecycle events delivered to webhook subscribers
+const (
+	EventJobCreated = "job.created"
+	EventJobUpdated = "job.updated"
+	EventJobDeleted = "job.deleted"
+	EventJobExpired = "job.expired"
+)
+
+// Events lists every job lifecycle event
+var Events = []string{EventJobCreated, EventJobUpdated, EventJobDeleted, EventJobExpired}
+
+// OutboxEvent is a job lifecycle event waiting to be delivered
+type OutboxEvent struct {
+	ID        string
+	Event     string
+	JobID     string
+	Payload   string
+	CreatedAt string
+}
+
+// EventPayload is the JSON body sent to webhook subscribers
+type EventPayload struct {
+	ID        string `json:"id"`
+	Event     string `json:"event"`
+	CreatedAt string `json:"created_at"`
+	Data      Job    `json:"data"`
+}
+
+// enqueueEvent records an event in the outbox. It must run in the same
+// transaction as the change it describes so events are never lost or
+// sent for changes that were rolled back.
+func enqueueEvent(ctx context.Context, exec execer, event string, job Job) error {
+	payload := EventPayload{
+		ID:        uuid.New().String(),
+		Event:     event,
+		CreatedAt: time.Now().UTC().Format(DateFormat),
+		Data:      job,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO webhook_outbox(id, event, job_id, payload, created_at)
+		VALUES(?, ?, ?, ?, ?)
+	`
+	_, err = exec.ExecContext(ctx, query, payload.ID, event, job.ID, string(body), payload.CreatedAt)
+
+	return err
+}
+
+// enqueueChange records the event for a job changing from before to after,
+// where nil means the job doesn't exist. Subscribers only hear about jobs
+// the public can see: a job is created for them when it becomes public and
+// deleted, as they last saw it, when it stops being public.
+func enqueueChange(ctx context.Context, exec execer, before, after *Job) error {
+	wasPublic := before != nil && before.IsPublic()
+	isPublic := after != nil && after.IsPublic()
+
+	switch {
+	case isPublic && !wasPublic:
+		return enqueueEvent(ctx, exec, EventJobCreated, *after)
+	case isPublic:
+		return enqueueEvent(ctx, exec, EventJobUpdated, *after)
+	case wasPublic:
+		return enqueueEvent(ctx, exec, EventJobDeleted, *before)
+	}
+	return nil
+}
+
+// EnqueueExpiredJobEvents queues a job.expired event for every public job
+// that has passed its expiry date and has not been announced yet
+func EnqueueExpiredJobEvents(ctx context.Context) (int, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT ` + jobColumns + ` FROM jobs
+		WHERE status = ? AND hidden = 0
+		AND julianday(` + publishedAtColumn + `) <= julianday('now', ?)
+		AND id NOT IN (SELECT job_id FROM webhook_outbox WHERE event = ?)
+	`
+
+	rows, err := db.DB.QueryContext(ctx, query, StatusPublished, "-"+strconv.Itoa(ExpirationDays)+" days", EventJobExpired)
+	if err != nil {
+		return 0, err
+	}
+
+	var jobs []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, job := range jobs {
+		if err := enqueueEvent(ctx, db.DB, EventJobExpired, job); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(jobs), nil
+}
+
+// GetPendingOutboxEvents returns events that have not been fanned out yet,
+// oldest first
+func GetPendingOutboxEvents(ctx context.Context, limit int) ([]OutboxEvent, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, event, job_id, payload, created_at FROM webhook_outbox
+		WHERE processed_at IS NULL
+		ORDER BY created_at ASC
+		LIMIT ?
+	`
+
+	rows, err := db.DB.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		err := rows.Scan(&event.ID, &event.Event, &event.JobID, &event.Payload, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// FanOut creates a pending delivery of the event for each subscriber and
+// marks the event as processed, all in one transaction
+func (event OutboxEvent) FanOut(ctx context.Context, webhooks []Webhook) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(DateFormat)
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Event) {
+			continue
+		}
+
+		delivery := WebhookDelivery{
+			ID:            uuid.New().String(),
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			Event:         event.Event,
+			Payload:       event.Payload,
+			Status:        DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := delivery.insert(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE webhook_outbox SET processed_at = ? WHERE id = ?", now, event.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// JobRevision is a previous version of a job, saved when it was edited.
+// Revisions are numbered from 1 for each job; the job as it is now is
+// newer than its highest revision.
+type JobRevision struct {
+	JobID     string `json:"job_id"`
+	Revision  int    `json:"revision"`
+	Actor     string `json:"actor"`
+	CreatedAt string `json:"created_at"`
+	Job       Job    `json:"job"`
+}
+
+// RevisionDiff is the structured difference between two versions of a job
+type RevisionDiff struct {
+	JobID   string                 `json:"job_id"`
+	From    int                    `json:"from"`
+	To      int                    `json:"to"`
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// CurrentRevision stands for the job as it is now when comparing revisions
+const CurrentRevision = 0
+
+// saveRevision stores a snapshot of the job before it is overwritten.
+// actor is the user whose edit replaced this version.
+func saveRevision(ctx context.Context, tx *sql.Tx, job Job, actor string) error {
+	snapshot, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	var revision int
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(revision), 0) + 1 FROM job_revisions WHERE job_id = ?", job.ID).Scan(&revision)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO job_revisions(job_id, revision, actor, snapshot, created_at)
+		VALUES(?, ?, ?, ?, ?)
+	`
+	_, err = tx.ExecContext(ctx, query, job.ID, revision, actor, string(snapshot), time.Now().UTC().Format(DateFormat))
+
+	return err
+}
+
+// scanRevision reads a revision from a row returned by SELECT * FROM job_revisions
+func scanRevision(row rowScanner) (JobRevision, error) {
+	var revision JobRevision
+	var snapshot string
+
+	err := row.Scan(
+		&revision.JobID,
+		&revision.Revision,
+		&revision.Actor,
+		&snapshot,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		return revision, err
+	}
+
+	if err := json.Unmarshal([]byte(snapshot), &revision.Job); err != nil {
+		return revision, err
+	}
+
+	// Expiry depends on today's date, not the date of the snapshot
+	revision.Job.Expired = revision.Job.IsExpired()
+
+	return revision, nil
+}
+
+// Get every revision of a job, oldest first
+func GetJobRevisions(ctx context.Context, jobID string) ([]JobRevision, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.DB.QueryContext(ctx, "SELECT * FROM job_revisions WHERE job_id = ? ORDER BY revision ASC", jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []JobRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// Get a single revision of a job
+func GetJobRevision(ctx context.Context, jobID string, revision int) (JobRevision, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	row := db.DB.QueryRowContext(ctx, "SELECT * FROM job_revisions WHERE job_id = ? AND revision = ?", jobID, revision)
+	return scanRevision(row)
+}
+
+// versionOf returns a revision of a job, or the job as it is now
+// for CurrentRevision
+func versionOf(ctx context.Context, jobID string, revision int) (Job, error) {
+	if revision == CurrentRevision {
+		return GetJobByID(ctx, jobID)
+	}
+
+	jobRevision, err := GetJobRevision(ctx, jobID, revision)
+	return jobRevision.Job, err
+}
+
+// DiffJobRevisions compares two versions of a job. Either side may be
+// CurrentRevision to compare against the job as it is now.
+func DiffJobRevisions(ctx context.Context, jobID string, from, to int) (RevisionDiff, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	before, err := versionOf(ctx, jobID, from)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	after, err := versionOf(ctx, jobID, to)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	return RevisionDiff{
+		JobID:   jobID,
+		From:    from,
+		To:      to,
+		Changes: diffJobs(&before, &after),
+	}, nil
+}
+
+// GetJobAt returns a job as it looked at the given time. A revision holds
+// the job up to the moment it was replaced, so the version in effect at
+// that time is the first revision replaced after it, or else the job as
+// it is now.
+func GetJobAt(ctx context.Context, jobID string, at time.Time) (Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	job, err := GetJobByID(ctx, jobID)
+	if err != nil {
+		return job, err
+	}
+
+	createdAt, err := time.Parse(DateFormat, job.CreatedAt)
+	if err == nil && at.Before(createdAt) {
+		return Job{}, sql.ErrNoRows
+	}
+
+	query := `
+		SELECT * FROM job_revisions
+		WHERE job_id = ? AND created_at > ?
+		ORDER BY revision ASC
+		LIMIT 1
+	`
+	revision, err := scanRevision(db.DB.QueryRowContext(ctx, query, jobID, at.UTC().Format(DateFormat)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, nil
+	}
+
+	return revision.Job, err
+}
+
+// RevertJob rolls a job back to one of its revisions. The revert is an
+// ordinary edit, so the version it replaces becomes a new revision and
+// only the job's owner and admins may revert it.
+func RevertJob(ctx context.Context, jobID string, revision int, actor, role string) (Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", jobID))
+	if err != nil {
+		return Job{}, err
+	}
+
+	if !CanEdit(before, actor, role) {
+		return Job{}, ErrForbidden
+	}
+
+	query := "SELECT * FROM job_revisions WHERE job_id = ? AND revision = ?"
+	target, err := scanRevision(tx.QueryRowContext(ctx, query, jobID, revision))
+	if err != nil {
+		return Job{}, err
+	}
+
+	dutiesJSON, err := json.Marshal(target.Job.Duties)
+	if err != nil {
+		return Job{}, err
+	}
+
+	job, err := applyUpdate(ctx, tx, before, target.Job, string(dutiesJSON), actor)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return job, tx.Commit()
+}
@@ -0,0 +1,358 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription to job lifecycle events
+type Webhook struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url" binding:"required,url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// WebhookDelivery is an attempt to send one event to one subscriber
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        string          `json:"-"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status"`
+	LastError      string          `json:"last_error"`
+	NextAttemptAt  string          `json:"next_attempt_at"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"updated_at"`
+	Body           json.RawMessage `json:"payload"`
+}
+
+// Subscribes reports whether the webhook wants the event.
+// A webhook with no events listed receives all of them.
+func (webhook Webhook) Subscribes(event string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, e := range webhook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Save webhook into the database
+func (webhook *Webhook) Save(ctx context.Context) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	webhook.ID = uuid.New().String()
+	webhook.Active = true
+	webhook.CreatedAt = time.Now().Format(DateFormat)
+
+	eventsJSON, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO webhooks(id, url, secret, events, active, created_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+	`
+	_, err = db.DB.ExecContext(ctx, query,
+		webhook.ID,
+		webhook.URL,
+		webhook.Secret,
+		string(eventsJSON),
+		webhook.Active,
+		webhook.CreatedAt,
+	)
+
+	return err
+}
+
+// scanWebhook reads a webhook from a row returned by SELECT * FROM webhooks
+func scanWebhook(row rowScanner) (Webhook, error) {
+	var webhook Webhook
+	var eventsJSON string
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		&eventsJSON,
+		&webhook.Active,
+		&webhook.CreatedAt,
+	)
+	if err != nil {
+		return webhook, err
+	}
+
+	err = json.Unmarshal([]byte(eventsJSON), &webhook.Events)
+
+	return webhook, err
+}
+
+// Get all webhooks
+func GetAllWebhooks(ctx context.Context) ([]Webhook, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.DB.QueryContext(ctx, "SELECT * FROM webhooks ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// Get active webhooks
+func GetActiveWebhooks(ctx context.Context) ([]Webhook, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	webhooks, err := GetAllWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active := []Webhook{}
+	for _, webhook := range webhooks {
+		if webhook.Active {
+			active = append(active, webhook)
+		}
+	}
+
+	return active, nil
+}
+
+// Get a webhook by ID
+func GetWebhookByID(ctx context.Context, id string) (Webhook, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	row := db.DB.QueryRowContext(ctx, "SELECT * FROM webhooks WHERE id = ?", id)
+	return scanWebhook(row)
+}
+
+// Update a webhook by ID
+func UpdateWebhookByID(ctx context.Context, id string, updatedWebhook Webhook) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	eventsJSON, err := json.Marshal(updatedWebhook.Events)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE webhooks
+		SET url = ?, events = ?, active = ?
+		WHERE id = ?
+	`
+	_, err = db.DB.ExecContext(ctx, query,
+		updatedWebhook.URL,
+		string(eventsJSON),
+		updatedWebhook.Active,
+		id,
+	)
+
+	return err
+}
+
+// Delete a webhook and its delivery log
+func (webhook Webhook) Delete(ctx context.Context) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = ?", webhook.ID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", webhook.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insert writes a new delivery using the given database handle or transaction
+func (delivery *WebhookDelivery) insert(ctx context.Context, exec execer) error {
+	query := `
+		INSERT INTO webhook_deliveries(id, webhook_id, event_id, event, payload, status,
+			attempts, response_status, last_error, next_attempt_at, created_at, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := exec.ExecContext(ctx, query,
+		delivery.ID,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+		delivery.UpdatedAt,
+	)
+
+	return err
+}
+
+// scanDelivery reads a delivery from a row returned by SELECT * FROM webhook_deliveries
+func scanDelivery(row rowScanner) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	delivery.Body = json.RawMessage(delivery.Payload)
+
+	return delivery, err
+}
+
+// queryDeliveries runs a query over webhook_deliveries and scans every row
+func queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]WebhookDelivery, error) {
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// Get the delivery log of a webhook, most recent first
+func GetWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT * FROM webhook_deliveries WHERE webhook_id = ? ORDER BY created_at DESC LIMIT ?"
+	return queryDeliveries(ctx, query, webhookID, limit)
+}
+
+// Get a single delivery of a webhook
+func GetWebhookDelivery(ctx context.Context, webhookID, id string) (WebhookDelivery, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	row := db.DB.QueryRowContext(ctx, "SELECT * FROM webhook_deliveries WHERE webhook_id = ? AND id = ?", webhookID, id)
+	return scanDelivery(row)
+}
+
+// GetDueWebhookDeliveries returns pending deliveries whose next attempt is due
+func GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT * FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+	`
+	return queryDeliveries(ctx, query, DeliveryPending, now.UTC().Format(DateFormat), limit)
+}
+
+// Redeliver queues a fresh copy of the delivery to be sent again
+func (delivery WebhookDelivery) Redeliver(ctx context.Context) (WebhookDelivery, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	now := time.Now().UTC().Format(DateFormat)
+
+	redelivery := WebhookDelivery{
+		ID:            uuid.New().String(),
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Body:          delivery.Body,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	return redelivery, redelivery.insert(ctx, db.DB)
+}
+
+// RecordAttempt stores the outcome of a delivery attempt
+func (delivery *WebhookDelivery) RecordAttempt(ctx context.Context) error {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	delivery.UpdatedAt = time.Now().UTC().Format(DateFormat)
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_status = ?, last_error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ?
+	`
+	_, err := db.DB.ExecContext(ctx, query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.UpdatedAt,
+		delivery.ID,
+	)
+
+	return err
+}
@@ -0,0 +1,274 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// Job statuses. Only published jobs are listed publicly.
+const (
+	StatusDraft         = "draft"
+	StatusPendingReview = "pending_review"
+	StatusScheduled     = "scheduled"
+	StatusPublished     = "published"
+	StatusClosed        = "closed"
+	// StatusHeld jobs looked like spam when posted and wait for a moderator
+	StatusHeld = "held"
+)
+
+// Roles a user can act in
+const (
+	RoleEmployer = "employer"
+	RoleReviewer = "reviewer"
+	RoleAdmin    = "admin"
+)
+
+// Workflow actions that move a job between statuses
+const (
+	ActionSubmit   = "submit"
+	ActionWithdraw = "withdraw"
+	ActionApprove  = "approve"
+	ActionReject   = "reject"
+	ActionPublish  = "publish"
+	ActionClose    = "close"
+	ActionRelease  = "release"
+)
+
+// Actors recorded in the audit log for changes no user made themselves
+const (
+	// SystemActor is recorded for changes made by background jobs
+	SystemActor = "system"
+	// AnonymousActor is recorded for requests without an authenticated
+	// user. It is never an owner.
+	AnonymousActor = "anonymous"
+)
+
+var (
+	// ErrUnknownAction is returned for an action the workflow doesn't define
+	ErrUnknownAction = errors.New("unknown action")
+	// ErrInvalidTransition is returned when the job's status doesn't allow the action
+	ErrInvalidTransition = errors.New("action not allowed in the job's current status")
+	// ErrForbidden is returned when the user may not perform the action
+	ErrForbidden = errors.New("user may not perform this action")
+	// ErrCommentRequired is returned when rejecting a job without saying why
+	ErrCommentRequired = errors.New("a comment is required")
+	// ErrOwnerRequired is returned when creating a job without a user to own it
+	ErrOwnerRequired = errors.New("jobs must be created by an authenticated user")
+)
+
+// transition is an edge in the publishing state machine
+type transition struct {
+	from  []string
+	to    string
+	roles []string
+	// ownerOnly restricts employers to their own jobs
+	ownerOnly bool
+}
+
+// transitions defines who may move a job from which statuses by each action.
+// Approving a job with a future publish time schedules it instead, and the
+// publish action is then taken by the scheduler when that time comes.
+var transitions = map[string]transition{
+	ActionSubmit:   {from: []string{StatusDraft}, to: StatusPendingReview, roles: []string{RoleEmployer, RoleAdmin}, ownerOnly: true},
+	ActionWithdraw: {from: []string{StatusPendingReview, StatusScheduled}, to: StatusDraft, roles: []string{RoleEmployer, RoleAdmin}, ownerOnly: true},
+	ActionApprove:  {from: []string{StatusPendingReview}, to: StatusPublished, roles: []string{RoleReviewer, RoleAdmin}},
+	ActionReject:   {from: []string{StatusPendingReview}, to: StatusDraft, roles: []string{RoleReviewer, RoleAdmin}},
+	ActionPublish:  {from: []string{StatusScheduled}, to: StatusPublished, roles: []string{RoleAdmin}},
+	ActionClose:    {from: []string{StatusScheduled, StatusPublished}, to: StatusClosed, roles: []string{RoleEmployer, RoleAdmin}, ownerOnly: true},
+	ActionRelease:  {from: []string{StatusHeld}, to: StatusDraft, roles: []string{RoleReviewer, RoleAdmin}},
+}
+
+// TransitionRequest asks for a job to be moved along the workflow
+type TransitionRequest struct {
+	Action    string
+	Actor     string
+	Role      string
+	Comment   string
+	PublishAt time.Time
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPublic reports whether anyone may see the job
+func (job *Job) IsPublic() bool {
+	return (job.Status == StatusPublished || job.Status == StatusClosed) && !job.Hidden
+}
+
+// OwnedBy reports whether user owns the job. Anonymous users own nothing.
+func (job *Job) OwnedBy(user string) bool {
+	return user != "" && user != AnonymousActor && job.OwnerID == user
+}
+
+// CanView reports whether a user may see a job. Published and closed jobs
+// are public unless they were hidden; anything else is only visible to its
+// owner and to staff.
+func CanView(job Job, user, role string) bool {
+	switch {
+	case job.IsPublic():
+		return true
+	case role == RoleReviewer || role == RoleAdmin:
+		return true
+	default:
+		return job.OwnedBy(user)
+	}
+}
+
+// CanEdit reports whether a user may edit, delete, revert or restore a
+// job. Only its owner and admins may.
+func CanEdit(job Job, user, role string) bool {
+	return role == RoleAdmin || job.OwnedBy(user)
+}
+
+// TransitionJob moves a job to a new status if the workflow allows it
+func TransitionJob(ctx context.Context, id string, request TransitionRequest) (Job, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	rule, ok := transitions[request.Action]
+	if !ok {
+		return Job{}, ErrUnknownAction
+	}
+
+	if !contains(rule.roles, request.Role) {
+		return Job{}, ErrForbidden
+	}
+
+	if request.Action == ActionReject && request.Comment == "" {
+		return Job{}, ErrCommentRequired
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+	if err != nil {
+		return Job{}, err
+	}
+
+	if rule.ownerOnly && request.Role == RoleEmployer && !before.OwnedBy(request.Actor) {
+		return Job{}, ErrForbidden
+	}
+
+	if !contains(rule.from, before.Status) {
+		return Job{}, ErrInvalidTransition
+	}
+
+	after := before
+	after.Status = rule.to
+
+	switch request.Action {
+	case ActionSubmit:
+		after.ReviewComment = ""
+	case ActionWithdraw:
+		after.PublishAt = ""
+	case ActionApprove:
+		after.ReviewComment = request.Comment
+		if request.PublishAt.After(time.Now()) {
+			after.Status = StatusScheduled
+			after.PublishAt = request.PublishAt.UTC().Format(DateFormat)
+		}
+	case ActionReject:
+		after.ReviewComment = request.Comment
+	}
+
+	job, err := applyTransition(ctx, tx, before, after, request.Action, request.Actor)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return job, tx.Commit()
+}
+
+// applyTransition stores the workflow fields of after and records the change
+func applyTransition(ctx context.Context, tx *sql.Tx, before, after Job, action, actor string) (Job, error) {
+	// A job is open for a while from when it is first published
+	var publishedAt sql.NullString
+	if after.Status == StatusPublished {
+		publishedAt = nullString(time.Now().UTC().Format(DateFormat))
+	}
+
+	query := `
+		UPDATE jobs
+		SET status = ?, publish_at = ?, review_comment = ?, published_at = COALESCE(published_at, ?)
+		WHERE id = ?
+	`
+	_, err := tx.ExecContext(ctx, query, after.Status, nullString(after.PublishAt), after.ReviewComment, publishedAt, before.ID)
+	if err != nil {
+		return Job{}, err
+	}
+
+	job, err := scanJob(tx.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = ?", before.ID))
+	if err != nil {
+		return Job{}, err
+	}
+
+	if err := recordJobEvent(ctx, tx, actor, action, &before, &job); err != nil {
+		return Job{}, err
+	}
+
+	if err := enqueueChange(ctx, tx, &before, &job); err != nil {
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+// PublishDueJobs publishes every scheduled job whose publish time has
+// passed and returns how many were published
+func PublishDueJobs(ctx context.Context, now time.Time) (int, error) {
+	ctx, cancel := db.WithTimeout(ctx)
+	defer cancel()
+
+	query := "SELECT id FROM jobs WHERE status = ? AND publish_at <= ?"
+	rows, err := db.DB.QueryContext(ctx, query, StatusScheduled, now.UTC().Format(DateFormat))
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, id := range ids {
+		request := TransitionRequest{Action: ActionPublish, Actor: SystemActor, Role: RoleAdmin}
+		_, err := TransitionJob(ctx, id, request)
+
+		// The job may have been withdrawn or closed since it was selected
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
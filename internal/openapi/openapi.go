@@ -0,0 +1,11 @@
+// Package openapi holds the OpenAPI document describing the HTTP API.
+// Keep openapi.json in step with the routes in handlers.RegisterRoutes;
+// the tests fail when a route is missing from it.
+package openapi
+
+import _ "embed"
+
+// Spec is the OpenAPI 3.1 document of the API, in JSON
+//
+//go:embed openapi.json
+var Spec []byte
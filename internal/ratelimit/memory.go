@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps token buckets in the process. Limits are per server,
+// so behind a load balancer each server allows the full limit.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// sweptAt is when idle buckets were last removed
+	sweptAt time.Time
+}
+
+// sweepInterval is how often buckets that have refilled completely are
+// dropped, keeping memory bounded however many clients come and go
+const sweepInterval = time.Minute
+
+// NewMemoryStore returns an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+// Take takes a token from the bucket for key, creating a full bucket on first use
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.sweptAt) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		s.buckets[key] = b
+	}
+
+	result := b.take(limit, now)
+	b.expires = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// sweep removes buckets that are full again, as a new bucket is the same
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.expires) {
+			delete(s.buckets, key)
+		}
+	}
+	s.sweptAt = now
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/auth"
+	"github.com/Ademayowa/job-board/internal/logging"
+	"github.com/Ademayowa/job-board/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc names the client a request is counted against
+type KeyFunc func(context *gin.Context) string
+
+// ClientKey counts requests against the API key if a valid one was sent,
+// then the user authenticated by the gateway, then the client IP address.
+// Only what auth.Middleware verified is used, so clients can't spread
+// their requests over made up keys or users.
+func ClientKey(context *gin.Context) string {
+	identity := auth.FromContext(context)
+	switch {
+	case identity.APIKey != "":
+		return "key:" + identity.APIKey
+	case identity.User != "":
+		return "user:" + identity.User
+	default:
+		return "ip:" + context.ClientIP()
+	}
+}
+
+// Middleware limits the routes listed in limits, keyed by method and
+// route pattern such as "POST /jobs" or "GET /jobs/:id". Other routes
+// are not limited. Every limited response carries RateLimit-* headers,
+// and rejected ones a Retry-After header as well.
+//
+// Route patterns are relative to base, the path of the route group the
+// middleware is used on, so the same route under several groups, e.g.
+// /jobs and /api/v1/jobs, shares its limit.
+//
+// If the store fails the request is let through: an outage of the store
+// shouldn't take the API down with it.
+func Middleware(store Store, limits map[string]Limit, key KeyFunc, base string) gin.HandlerFunc {
+	base = strings.TrimSuffix(base, "/")
+
+	return func(context *gin.Context) {
+		route := context.Request.Method + " " + strings.TrimPrefix(context.FullPath(), base)
+		limit, ok := limits[route]
+		if !ok || limit.Requests <= 0 {
+			context.Next()
+			return
+		}
+
+		result, err := store.Take(context.Request.Context(), route+"|"+key(context), limit, time.Now())
+		if err != nil {
+			logging.FromContext(context.Request.Context()).Warn("could not check rate limit", "route", route, "error", err)
+			context.Next()
+			return
+		}
+
+		header := context.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		header.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(ceilSeconds(limit.Per)))
+
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			response.Error(context, http.StatusTooManyRequests, "too many requests, try again later")
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Requests requests per Per period. Requests is also the
+// burst size: a client that has been quiet can send that many at once.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// rate is the number of tokens a bucket regains per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result is the state of a bucket after a request tried to take a token
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a token is available again, zero if
+	// the request was allowed
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// Store keeps token buckets and takes tokens from them atomically.
+// MemoryStore keeps buckets in the process, RedisStore shares them
+// between servers.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// bucket is a token bucket as it was at a point in time
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// expires is when the bucket will be full again
+	expires time.Time
+}
+
+// take refills the bucket for the time passed since it was last used and
+// tries to take a token from it
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*rate)
+		b.updated = now
+	}
+
+	result := Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(b.tokens))
+	result.ResetAfter = seconds((capacity - b.tokens) / rate)
+	return result
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisClient is the one Redis command RedisStore needs. go-redis clients
+// satisfy it through a small adapter calling Eval(...).Result(), and
+// tests can fake it without a server.
+type RedisClient interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// TokenBucketScript refills and takes from a bucket stored as a hash with
+// the fields tokens and updated (Unix milliseconds), in a single atomic step.
+//
+// KEYS[1] is the bucket, ARGV is the capacity, the refill rate in tokens
+// per millisecond and the current time in Unix milliseconds. It returns
+// whether the request was allowed, the whole tokens left, and the
+// milliseconds until a token is available and until the bucket is full.
+const TokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1]) or capacity
+local updated = tonumber(state[2]) or now
+
+if now > updated then
+	tokens = math.min(capacity, tokens + (now - updated) * rate)
+	updated = now
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", updated)
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+
+return {allowed, math.floor(tokens), retry, reset}
+`
+
+// RedisStore keeps token buckets in Redis so every server shares the limits
+type RedisStore struct {
+	Client RedisClient
+	// Prefix is put in front of every bucket key
+	Prefix string
+}
+
+// NewRedisStore returns a store keeping buckets under the "ratelimit:" prefix
+func NewRedisStore(client RedisClient) *RedisStore {
+	return &RedisStore{Client: client, Prefix: "ratelimit:"}
+}
+
+// Take takes a token from the bucket for key using TokenBucketScript
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	ratePerMs := limit.rate() / 1000
+	reply, err := s.Client.Eval(ctx, TokenBucketScript, []string{s.Prefix + key},
+		limit.Requests, strconv.FormatFloat(ratePerMs, 'g', -1, 64), now.UnixMilli())
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", reply)
+	}
+
+	numbers := make([]int64, len(values))
+	for i, value := range values {
+		n, ok := value.(int64)
+		if !ok {
+			return Result{}, fmt.Errorf("ratelimit: unexpected reply %v", reply)
+		}
+		numbers[i] = n
+	}
+
+	return Result{
+		Allowed:    numbers[0] == 1,
+		Limit:      limit.Requests,
+		Remaining:  int(math.Max(0, float64(numbers[1]))),
+		RetryAfter: time.Duration(numbers[2]) * time.Millisecond,
+		ResetAfter: time.Duration(numbers[3]) * time.Millisecond,
+	}, nil
+}
+
+// goRedis adapts a go-redis client to RedisClient
+type goRedis struct {
+	client redis.Scripter
+}
+
+func (g goRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return g.client.Eval(ctx, script, keys, args...).Result()
+}
+
+// DialRedis returns a store using the Redis server at rawURL, such as
+// redis://:password@localhost:6379/0. It doesn't connect until the first
+// request is limited.
+func DialRedis(rawURL string) (*RedisStore, error) {
+	options, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: invalid Redis URL: %w", err)
+	}
+	return NewRedisStore(goRedis{client: redis.NewClient(options)}), nil
+}
@@ -0,0 +1,107 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ademayowa/job-board/internal/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// ProblemContentType is the media type of problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem describes why a request failed, following RFC 7807
+type Problem struct {
+	// Type is a URI naming the kind of problem. It is about:blank when
+	// the status code says it all, and Title is then the status text.
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Detail explains this occurrence of the problem to the client
+	Detail string `json:"detail,omitempty"`
+	// Instance is the path of the request that failed
+	Instance string `json:"instance,omitempty"`
+	// Errors lists the fields that failed validation
+	Errors validation.Errors `json:"errors,omitempty"`
+	// Extensions are extra members, such as the IDs of duplicate jobs
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// NewProblem returns a problem of type about:blank
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// With returns the problem with an extension member added
+func (p Problem) With(name string, value interface{}) Problem {
+	extensions := make(map[string]interface{}, len(p.Extensions)+1)
+	for key, existing := range p.Extensions {
+		extensions[key] = existing
+	}
+	extensions[name] = value
+	p.Extensions = extensions
+	return p
+}
+
+// MarshalJSON writes the extensions alongside the standard members
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	standard, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return standard, err
+	}
+
+	members := map[string]interface{}{}
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+	if err := json.Unmarshal(standard, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// Fail responds with problem and stops the remaining handlers. Without
+// the envelope, the detail is the error member of a plain JSON object, next
+// to the validation errors and the extensions.
+func Fail(context *gin.Context, problem Problem) {
+	if !Enveloped(context) {
+		body := gin.H{"error": problem.Detail}
+		if problem.Errors != nil {
+			body["errors"] = problem.Errors
+		}
+		for name, value := range problem.Extensions {
+			body[name] = value
+		}
+		context.AbortWithStatusJSON(problem.Status, body)
+		return
+	}
+
+	if problem.Instance == "" {
+		problem.Instance = context.Request.URL.Path
+	}
+
+	context.Header("Content-Type", ProblemContentType)
+	context.Render(problem.Status, render.JSON{Data: problem})
+	context.Abort()
+}
+
+// Error responds with a problem of type about:blank
+func Error(context *gin.Context, status int, detail string) {
+	Fail(context, NewProblem(status, detail))
+}
+
+// Invalid responds 422 listing the fields that failed validation
+func Invalid(context *gin.Context, detail string, errs validation.Errors) {
+	problem := NewProblem(http.StatusUnprocessableEntity, detail)
+	problem.Errors = errs
+	Fail(context, problem)
+}
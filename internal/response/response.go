@@ -0,0 +1,94 @@
+// Package response writes the bodies of JSON responses. Requests to the
+// versions of the API that call UseEnvelope get their payload wrapped in an
+// Envelope, and errors as problem details as described by RFC 7807. The
+// others get the bodies the API has always sent: bare payloads, and errors
+// as an object with an error member.
+package response
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// envelopeKey is the key under which a request records that its
+// responses use the envelope
+const envelopeKey = "response_envelope"
+
+// UseEnvelope makes the responses to the request use the envelope and
+// problem details
+func UseEnvelope(context *gin.Context) {
+	context.Set(envelopeKey, true)
+}
+
+// Enveloped reports whether the responses to the request use the envelope
+// and problem details
+func Enveloped(context *gin.Context) bool {
+	return context.GetBool(envelopeKey)
+}
+
+// Envelope is the body of every successful JSON response that uses it
+type Envelope struct {
+	Data interface{} `json:"data"`
+	// Message says what was done, e.g. "job created"
+	Message string `json:"message,omitempty"`
+	// Pagination is set when Data is one page of a longer list
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination locates a page in a list
+type Pagination struct {
+	CurrentPage int `json:"current_page"`
+	PerPage     int `json:"per_page"`
+	Total       int `json:"total"`
+	TotalPages  int `json:"total_pages"`
+}
+
+// NewPagination describes page of a list of total items, perPage a page
+func NewPagination(page, perPage, total int) *Pagination {
+	return &Pagination{
+		CurrentPage: page,
+		PerPage:     perPage,
+		Total:       total,
+		TotalPages:  int(math.Ceil(float64(total) / float64(perPage))),
+	}
+}
+
+// OK responds 200 with data
+func OK(context *gin.Context, data interface{}) {
+	if !Enveloped(context) {
+		context.JSON(http.StatusOK, data)
+		return
+	}
+	context.JSON(http.StatusOK, Envelope{Data: data})
+}
+
+// Message responds with data and a message saying what was done. Without
+// the envelope, data sits beside the message under name, or, when name is
+// empty, the members of data do.
+func Message(context *gin.Context, status int, message, name string, data interface{}) {
+	if !Enveloped(context) {
+		body := gin.H{"message": message}
+		if members, ok := data.(gin.H); ok && name == "" {
+			for key, value := range members {
+				body[key] = value
+			}
+		} else if data != nil {
+			body[name] = data
+		}
+		context.JSON(status, body)
+		return
+	}
+	context.JSON(status, Envelope{Data: data, Message: message})
+}
+
+// Page responds 200 with one page of a list. Without the envelope, the
+// pagination is given as metadata.
+func Page(context *gin.Context, data interface{}, pagination *Pagination) {
+	if !Enveloped(context) {
+		context.JSON(http.StatusOK, gin.H{"data": data, "metadata": pagination})
+		return
+	}
+	context.JSON(http.StatusOK, Envelope{Data: data, Pagination: pagination})
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// Publisher publishes scheduled jobs once their publish time has come
+type Publisher struct {
+	Interval time.Duration
+}
+
+// NewPublisher returns a publisher that checks for due jobs every minute
+func NewPublisher() *Publisher {
+	return &Publisher{Interval: time.Minute}
+}
+
+// Run publishes due jobs every Interval until ctx is cancelled
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.RunOnce(ctx); err != nil {
+			slog.Error("could not publish scheduled jobs", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes every job that is due now
+func (p *Publisher) RunOnce(ctx context.Context) error {
+	_, err := models.PublishDueJobs(ctx, time.Now())
+	return err
+}
@@ -0,0 +1,155 @@
+package seo
+
+import (
+	"strings"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/markup"
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// JobPosting is a schema.org JobPosting as expected by Google for Jobs.
+// See https://schema.org/JobPosting
+type JobPosting struct {
+	Context            string          `json:"@context"`
+	Type               string          `json:"@type"`
+	Title              string          `json:"title"`
+	Description        string          `json:"description"`
+	Identifier         PropertyValue   `json:"identifier"`
+	URL                string          `json:"url,omitempty"`
+	DatePosted         string          `json:"datePosted,omitempty"`
+	ValidThrough       string          `json:"validThrough,omitempty"`
+	HiringOrganization Organization    `json:"hiringOrganization"`
+	JobLocation        *Place          `json:"jobLocation,omitempty"`
+	JobLocationType    string          `json:"jobLocationType,omitempty"`
+	BaseSalary         *MonetaryAmount `json:"baseSalary,omitempty"`
+	Responsibilities   string          `json:"responsibilities,omitempty"`
+	DirectApply        bool            `json:"directApply"`
+	SameAs             string          `json:"sameAs,omitempty"`
+}
+
+// PropertyValue is a schema.org PropertyValue
+type PropertyValue struct {
+	Type  string `json:"@type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Organization is a schema.org Organization
+type Organization struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// Place is a schema.org Place
+type Place struct {
+	Type    string        `json:"@type"`
+	Address PostalAddress `json:"address"`
+}
+
+// PostalAddress is a schema.org PostalAddress
+type PostalAddress struct {
+	Type            string `json:"@type"`
+	AddressLocality string `json:"addressLocality"`
+}
+
+// MonetaryAmount is a schema.org MonetaryAmount
+type MonetaryAmount struct {
+	Type     string        `json:"@type"`
+	Currency string        `json:"currency"`
+	Value    QuantityValue `json:"value"`
+}
+
+// QuantityValue is a schema.org QuantitativeValue
+type QuantityValue struct {
+	Type     string  `json:"@type"`
+	Value    float64 `json:"value"`
+	UnitText string  `json:"unitText"`
+}
+
+// NewJobPosting maps a job onto a schema.org JobPosting.
+// detailsURL is the public job details page for the job, and site names
+// the hiring organization and the currency and unit of salaries.
+func NewJobPosting(job models.Job, detailsURL string, site config.Site) JobPosting {
+	posting := JobPosting{
+		Context:     "https://schema.org/",
+		Type:        "JobPosting",
+		Title:       job.Title,
+		Description: descriptionHTML(job),
+		Identifier: PropertyValue{
+			Type:  "PropertyValue",
+			Name:  site.OrganizationName,
+			Value: job.ID,
+		},
+		URL: detailsURL,
+		HiringOrganization: Organization{
+			Type: "Organization",
+			Name: site.OrganizationName,
+		},
+		Responsibilities: responsibilities(job),
+		SameAs:           job.Url,
+	}
+
+	if publishedAt := job.PublishedTime(); !publishedAt.IsZero() {
+		posting.DatePosted = publishedAt.Format(models.DateFormat)
+	}
+
+	if expiresAt := job.ExpiresAt(); !expiresAt.IsZero() {
+		posting.ValidThrough = expiresAt.Format(models.DateFormat)
+	}
+
+	// Google expects remote jobs to be marked as telecommute
+	// rather than given a street address
+	if strings.EqualFold(strings.TrimSpace(job.Location), "remote") {
+		posting.JobLocationType = "TELECOMMUTE"
+	} else if job.Location != "" {
+		posting.JobLocation = &Place{
+			Type: "Place",
+			Address: PostalAddress{
+				Type:            "PostalAddress",
+				AddressLocality: job.Location,
+			},
+		}
+	}
+
+	if job.Salary > 0 {
+		posting.BaseSalary = &MonetaryAmount{
+			Type:     "MonetaryAmount",
+			Currency: site.SalaryCurrency,
+			Value: QuantityValue{
+				Type:     "QuantitativeValue",
+				Value:    job.Salary,
+				UnitText: site.SalaryUnit,
+			},
+		}
+	}
+
+	return posting
+}
+
+// descriptionHTML joins the sanitized HTML of the description and duties,
+// which is the format search engines expect for JobPosting descriptions
+func descriptionHTML(job models.Job) string {
+	var b strings.Builder
+
+	b.WriteString(job.DescriptionHTML)
+
+	if len(job.DutiesHTML) > 0 {
+		b.WriteString("<ul>")
+		for _, duty := range job.DutiesHTML {
+			b.WriteString("<li>" + duty + "</li>")
+		}
+		b.WriteString("</ul>")
+	}
+
+	return b.String()
+}
+
+// responsibilities lists the duties as plain text, one per line
+func responsibilities(job models.Job) string {
+	duties := make([]string, len(job.DutiesHTML))
+	for i, duty := range job.DutiesHTML {
+		duties[i] = markup.PlainText(duty)
+	}
+	return strings.Join(duties, "\n")
+}
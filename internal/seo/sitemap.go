@@ -0,0 +1,59 @@
+package seo
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// MaxSitemapURLs is the most URLs a single sitemap file may list
+const MaxSitemapURLs = 50000
+
+// SitemapURL is a single <url> entry in a sitemap
+type SitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// SitemapWriter streams a sitemap one URL at a time.
+// See https://www.sitemaps.org/protocol.html
+type SitemapWriter struct {
+	encoder *xml.Encoder
+	count   int
+}
+
+// NewSitemapWriter writes the sitemap header and returns a writer for its URLs
+func NewSitemapWriter(w io.Writer) (*SitemapWriter, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+
+	encoder := xml.NewEncoder(w)
+	start := xml.StartElement{
+		Name: xml.Name{Local: "urlset"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "http://www.sitemaps.org/schemas/sitemap/0.9"}},
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return nil, err
+	}
+
+	return &SitemapWriter{encoder: encoder}, nil
+}
+
+// Full reports whether the sitemap has reached MaxSitemapURLs
+func (s *SitemapWriter) Full() bool {
+	return s.count >= MaxSitemapURLs
+}
+
+// Write adds a URL to the sitemap
+func (s *SitemapWriter) Write(url SitemapURL) error {
+	s.count++
+	return s.encoder.EncodeElement(url, xml.StartElement{Name: xml.Name{Local: "url"}})
+}
+
+// Close ends the sitemap document
+func (s *SitemapWriter) Close() error {
+	if err := s.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "urlset"}}); err != nil {
+		return err
+	}
+	return s.encoder.Flush()
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Certificate holds a TLS certificate loaded from disk and swaps it for
+// the new one when the files change, so renewed certificates are served
+// without a restart
+type Certificate struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// LoadCertificate loads the certificate and private key at the given paths
+func LoadCertificate(certFile, keyFile string) (*Certificate, error) {
+	certs := &Certificate{certFile: certFile, keyFile: keyFile}
+	if err := certs.Reload(); err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// GetCertificate returns the current certificate. It is meant for
+// tls.Config.GetCertificate.
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// Reload loads the files again if either changed since they were last
+// loaded. If the new pair is invalid, e.g. because only one of the files
+// has been replaced so far, the current certificate is kept.
+func (c *Certificate) Reload() error {
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	unchanged := c.cert != nil && modTime.Equal(c.modTime)
+	c.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("server: could not load certificate: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.modTime = modTime
+	c.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the certificate every interval until ctx is cancelled
+func (c *Certificate) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Reload(); err != nil {
+				slog.Error("could not reload TLS certificate", "error", err)
+			}
+		}
+	}
+}
+
+// latestModTime returns when the most recently changed of the files changed
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("server: could not read certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
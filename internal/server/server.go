@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+)
+
+// New returns an HTTP server for handler with the timeouts from cfg
+func New(handler http.Handler, cfg config.Server) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout.Duration(),
+		ReadTimeout:       cfg.ReadTimeout.Duration(),
+		WriteTimeout:      cfg.WriteTimeout.Duration(),
+		IdleTimeout:       cfg.IdleTimeout.Duration(),
+	}
+}
+
+// Run serves HTTP, or HTTPS if cfg has a certificate, until ctx is
+// cancelled. It then stops accepting connections and waits up to
+// cfg.ShutdownTimeout for in-flight requests to finish before closing
+// the rest. Run returns nil after a clean shutdown.
+func Run(ctx context.Context, handler http.Handler, cfg config.Server) error {
+	listener, err := net.Listen("tcp", cfg.Addr())
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, listener, handler, cfg)
+}
+
+// Serve is Run on a listener that is already open
+func Serve(ctx context.Context, listener net.Listener, handler http.Handler, cfg config.Server) error {
+	server := New(handler, cfg)
+
+	if cfg.TLS.Enabled() {
+		certs, err := LoadCertificate(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		// The watcher stops with the server
+		watchCtx, stopWatching := context.WithCancel(ctx)
+		defer stopWatching()
+		go certs.Watch(watchCtx, cfg.TLS.ReloadInterval.Duration())
+
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+		listener = tls.NewListener(listener, server.TLSConfig)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		// The server failed before it was asked to stop
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down, waiting for requests to finish", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration())
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// Requests still running after the deadline are cut off
+		server.Close()
+		return err
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Drain returns a context that is cancelled delay after ctx is. When ctx
+// is cancelled notify is called straight away, e.g. to fail readiness
+// checks, so load balancers stop sending requests before the server
+// stops accepting them.
+func Drain(ctx context.Context, delay time.Duration, notify func()) context.Context {
+	drained, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		defer cancel()
+		<-ctx.Done()
+		notify()
+
+		slog.Info("draining before shutting down", "delay", delay.String())
+		time.Sleep(delay)
+	}()
+
+	return drained
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Workers runs background workers and stops them one at a time, last
+// started first, so a worker can rely on everything started before it
+// until it has stopped
+type Workers struct {
+	workers []*worker
+}
+
+// worker is a running background worker
+type worker struct {
+	name string
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// Start runs fn in the background until Stop cancels its context
+func (w *Workers) Start(name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	running := &worker{name: name, stop: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(running.done)
+		fn(ctx)
+	}()
+
+	w.workers = append(w.workers, running)
+}
+
+// Stop stops the workers in the reverse order they were started, giving
+// each up to timeout to return. A worker that doesn't is logged and left
+// behind so the others can still stop.
+func (w *Workers) Stop(timeout time.Duration) {
+	for i := len(w.workers) - 1; i >= 0; i-- {
+		running := w.workers[i]
+		running.stop()
+
+		select {
+		case <-running.done:
+			slog.Info("stopped worker", "worker", running.name)
+		case <-time.After(timeout):
+			slog.Warn("worker did not stop in time", "worker", running.name, "timeout", timeout.String())
+		}
+	}
+	w.workers = nil
+}
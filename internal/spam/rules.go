@@ -0,0 +1,303 @@
+package spam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// Config is the contents of a rules file. Each rule adds its weight to
+// the score of a job that breaks it, and a weight of zero turns it off.
+type Config struct {
+	// HoldThreshold is the score at which a job is held for moderation
+	HoldThreshold float64 `json:"hold_threshold"`
+
+	Keywords KeywordRule `json:"keywords"`
+	URL      URLRule     `json:"url"`
+	Salary   SalaryRule  `json:"salary"`
+	Caps     CapsRule    `json:"caps"`
+	Links    LinksRule   `json:"links"`
+}
+
+// KeywordRule flags blocklisted words and phrases, counting each one found
+type KeywordRule struct {
+	Weight  float64  `json:"weight"`
+	Blocked []string `json:"blocked"`
+}
+
+// URLRule flags application links that hide where they lead
+type URLRule struct {
+	Weight float64 `json:"weight"`
+	// BlockedHosts are link shorteners and other hosts scams hide behind.
+	// Subdomains of a blocked host are blocked too.
+	BlockedHosts []string `json:"blocked_hosts"`
+	// RequireHTTPS flags plain http links
+	RequireHTTPS bool `json:"require_https"`
+}
+
+// SalaryRule flags salaries too good (or too low) to be true
+type SalaryRule struct {
+	Weight float64 `json:"weight"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// CapsRule flags shouting, once the text is long enough to judge
+type CapsRule struct {
+	Weight     float64 `json:"weight"`
+	MaxRatio   float64 `json:"max_ratio"`
+	MinLetters int     `json:"min_letters"`
+}
+
+// LinksRule flags descriptions stuffed with links
+type LinksRule struct {
+	Weight float64 `json:"weight"`
+	Max    int     `json:"max"`
+}
+
+// DefaultConfig is used when no rules file exists
+func DefaultConfig() Config {
+	return Config{
+		HoldThreshold: 10,
+		Keywords: KeywordRule{
+			Weight: 5,
+			Blocked: []string{
+				"wire transfer", "western union", "moneygram", "registration fee",
+				"training fee", "pay to apply", "upfront payment", "gift card",
+				"bitcoin", "earn $$$", "work from home and earn", "no experience needed",
+			},
+		},
+		URL: URLRule{
+			Weight:       5,
+			BlockedHosts: []string{"bit.ly", "tinyurl.com", "t.co", "goo.gl", "ow.ly", "is.gd", "buff.ly", "rebrand.ly", "cutt.ly"},
+		},
+		Salary: SalaryRule{Weight: 4, Min: 1000, Max: 1000000},
+		Caps:   CapsRule{Weight: 3, MaxRatio: 0.5, MinLetters: 20},
+		Links:  LinksRule{Weight: 3, Max: 3},
+	}
+}
+
+// Rules scores jobs against a Config that can be reloaded from its file
+// while the server runs
+type Rules struct {
+	path   string
+	config atomic.Pointer[Config]
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewRules returns rules using the given config that are never reloaded
+func NewRules(config Config) *Rules {
+	rules := &Rules{}
+	rules.config.Store(&config)
+	return rules
+}
+
+// LoadRules reads the rules from a JSON file. Settings missing from the
+// file keep their default, and if the file doesn't exist the defaults
+// are used until it is created.
+func LoadRules(path string) (*Rules, error) {
+	rules := NewRules(DefaultConfig())
+	rules.path = path
+
+	if err := rules.Reload(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Config returns the rules currently in force
+func (r *Rules) Config() Config {
+	return *r.config.Load()
+}
+
+// Reload rereads the rules file if it changed since it was last read.
+// A file that can't be parsed leaves the current rules in place.
+func (r *Rules) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, err := os.Stat(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(r.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+
+	config := DefaultConfig()
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("spam rules %s: %w", r.path, err)
+	}
+
+	r.config.Store(&config)
+	r.modTime = info.ModTime()
+	return nil
+}
+
+// Watch reloads the rules file every interval until ctx is cancelled
+func (r *Rules) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reload(); err != nil {
+				slog.Error("could not reload spam rules", "error", err)
+			}
+		}
+	}
+}
+
+// Score runs every rule against the job
+func (r *Rules) Score(job models.Job) Result {
+	config := r.Config()
+	text := jobText(job)
+
+	var result Result
+	result.add(config.Keywords.check(text))
+	result.add(config.URL.check(job.Url))
+	result.add(config.Salary.check(job.Salary))
+	result.add(config.Caps.check(job.Title + " " + job.Description))
+	result.add(config.Links.check(job.Description + " " + strings.Join(job.Duties, " ")))
+
+	return result
+}
+
+// jobText is all the free text of a job
+func jobText(job models.Job) string {
+	return strings.Join(append([]string{job.Title, job.Description}, job.Duties...), "\n")
+}
+
+func (rule KeywordRule) check(text string) Result {
+	var result Result
+	if rule.Weight == 0 {
+		return result
+	}
+
+	text = strings.ToLower(text)
+	for _, keyword := range rule.Blocked {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			result.Score += rule.Weight
+			result.Reasons = append(result.Reasons, fmt.Sprintf("contains blocked phrase %q", keyword))
+		}
+	}
+
+	return result
+}
+
+func (rule URLRule) check(link string) Result {
+	if rule.Weight == 0 || link == "" {
+		return Result{}
+	}
+
+	flag := func(reason string) Result {
+		return Result{Score: rule.Weight, Reasons: []string{reason}}
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Hostname() == "" {
+		return flag("url can't be parsed")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case parsed.User != nil:
+		return flag("url hides its host behind user info")
+	case net.ParseIP(host) != nil:
+		return flag("url points at an IP address")
+	case strings.HasPrefix(host, "xn--") || strings.Contains(host, ".xn--"):
+		return flag("url uses an internationalized host name")
+	case rule.RequireHTTPS && parsed.Scheme != "https":
+		return flag("url is not https")
+	}
+
+	for _, blocked := range rule.BlockedHosts {
+		blocked = strings.ToLower(blocked)
+		if host == blocked || strings.HasSuffix(host, "."+blocked) {
+			return flag("url uses blocked host " + blocked)
+		}
+	}
+
+	return Result{}
+}
+
+func (rule SalaryRule) check(salary float64) Result {
+	switch {
+	case rule.Weight == 0:
+		return Result{}
+	case rule.Max > 0 && salary > rule.Max:
+		return Result{Score: rule.Weight, Reasons: []string{"salary is unrealistically high"}}
+	case salary > 0 && salary < rule.Min:
+		return Result{Score: rule.Weight, Reasons: []string{"salary is unrealistically low"}}
+	}
+	return Result{}
+}
+
+func (rule CapsRule) check(text string) Result {
+	if rule.Weight == 0 {
+		return Result{}
+	}
+
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+
+	if letters < rule.MinLetters || letters == 0 {
+		return Result{}
+	}
+	if float64(upper)/float64(letters) > rule.MaxRatio {
+		return Result{Score: rule.Weight, Reasons: []string{"too much text in capitals"}}
+	}
+	return Result{}
+}
+
+// linkPattern matches web links written out in text or Markdown
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)`)
+
+func (rule LinksRule) check(text string) Result {
+	if rule.Weight == 0 {
+		return Result{}
+	}
+
+	if count := len(linkPattern.FindAllStringIndex(text, -1)); count > rule.Max {
+		return Result{Score: rule.Weight, Reasons: []string{fmt.Sprintf("contains %d links", count)}}
+	}
+	return Result{}
+}
@@ -0,0 +1,60 @@
+package spam
+
+import (
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// Result is how suspicious a scorer found a job and why
+type Result struct {
+	Score   float64
+	Reasons []string
+}
+
+// add merges another result into this one
+func (r *Result) add(other Result) {
+	r.Score += other.Score
+	r.Reasons = append(r.Reasons, other.Reasons...)
+}
+
+// Scorer rates how likely a job is to be spam or a scam. Higher is worse.
+// Rules implements it, and other scorers such as a trained model can be
+// added to a Pipeline alongside the rules.
+type Scorer interface {
+	Score(job models.Job) Result
+}
+
+// Verdict is the combined result of every scorer in a pipeline
+type Verdict struct {
+	Result
+	// Hold is set when the score reaches the hold threshold, meaning the
+	// job must be moderated before it can go through review
+	Hold bool
+}
+
+// Pipeline runs a job through the rules and any extra scorers and adds
+// up their scores
+type Pipeline struct {
+	rules   *Rules
+	scorers []Scorer
+}
+
+// NewPipeline returns a pipeline scoring jobs with the rules and then
+// each extra scorer in turn. The rules also set the hold threshold.
+func NewPipeline(rules *Rules, scorers ...Scorer) *Pipeline {
+	return &Pipeline{rules: rules, scorers: scorers}
+}
+
+// Check scores a job and decides whether it should be held
+func (p *Pipeline) Check(job models.Job) Verdict {
+	var verdict Verdict
+
+	verdict.add(p.rules.Score(job))
+	for _, scorer := range p.scorers {
+		verdict.add(scorer.Score(job))
+	}
+
+	threshold := p.rules.Config().HoldThreshold
+	verdict.Hold = threshold > 0 && verdict.Score >= threshold
+
+	return verdict
+}
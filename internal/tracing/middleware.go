@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"github.com/Ademayowa/job-board/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for every request, continuing the
+// trace of the caller if it sent a traceparent header. Spans are named
+// after the route pattern, e.g. "GET /jobs/:id", so they group well.
+func Middleware() gin.HandlerFunc {
+	tracer := otel.Tracer(instrumentation)
+
+	return func(context *gin.Context) {
+		request := context.Request
+		ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+		route := context.FullPath()
+		name := request.Method + " " + route
+		if route == "" {
+			name = request.Method
+		}
+
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		context.Request = request.WithContext(ctx)
+		context.Next()
+
+		// Only a user the auth middleware believed is recorded, never
+		// whoever the request claims to be from
+		if user := auth.FromContext(context).User; user != "" {
+			span.SetAttributes(attribute.String("enduser.id", user))
+		}
+
+		status := context.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, "")
+		}
+		if len(context.Errors) > 0 {
+			span.RecordError(context.Errors.Last())
+		}
+	}
+}
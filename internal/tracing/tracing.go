@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Ademayowa/job-board/internal/buildinfo"
+	"github.com/Ademayowa/job-board/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// instrumentation names the tracer of this module's spans
+const instrumentation = "github.com/Ademayowa/job-board"
+
+// Setup installs the global tracer provider and W3C trace context
+// propagation described by cfg. The stdout exporter writes to out.
+// The returned function flushes pending spans and must be called before
+// the process exits.
+func Setup(ctx context.Context, cfg config.Tracing, out io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "stdout":
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			return nil, err
+		}
+		exporter = stdout
+	case "otlp":
+		otlp, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("tracing: could not create OTLP exporter: %w", err)
+		}
+		exporter = otlp
+	default:
+		// Nothing is recorded, but trace IDs sent by callers are still
+		// carried through to the logs
+		return func(context.Context) error { return nil }, nil
+	}
+
+	attributes := resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(buildinfo.Get().Version),
+	)
+	res, err := resource.Merge(resource.Default(), attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error codes returned by the built-in rules
+const (
+	CodeRequired   = "required"
+	CodeTooLong    = "too_long"
+	CodeTooSmall   = "too_small"
+	CodeTooLarge   = "too_large"
+	CodeTooMany    = "too_many"
+	CodeInvalidURL = "invalid_url"
+	CodeNotAllowed = "not_allowed"
+)
+
+// Required rejects strings that are empty or only whitespace
+func Required() Rule[string] {
+	return func(value string) (string, string, bool) {
+		return CodeRequired, "is required", strings.TrimSpace(value) != ""
+	}
+}
+
+// NotZero rejects the zero value of a type, e.g. a missing number
+func NotZero[T comparable]() Rule[T] {
+	return func(value T) (string, string, bool) {
+		var zero T
+		return CodeRequired, "is required", value != zero
+	}
+}
+
+// NotEmpty rejects lists without any items
+func NotEmpty[T any]() Rule[[]T] {
+	return func(values []T) (string, string, bool) {
+		return CodeRequired, "is required", len(values) > 0
+	}
+}
+
+// MaxLength rejects strings longer than max characters
+func MaxLength(max int) Rule[string] {
+	return func(value string) (string, string, bool) {
+		return CodeTooLong, "must be at most " + strconv.Itoa(max) + " characters", utf8.RuneCountInString(value) <= max
+	}
+}
+
+// MaxItems rejects lists with more than max items
+func MaxItems[T any](max int) Rule[[]T] {
+	return func(values []T) (string, string, bool) {
+		return CodeTooMany, "must have at most " + strconv.Itoa(max) + " items", len(values) <= max
+	}
+}
+
+// Min rejects numbers below min
+func Min(min float64) Rule[float64] {
+	return func(value float64) (string, string, bool) {
+		return CodeTooSmall, "must be at least " + strconv.FormatFloat(min, 'f', -1, 64), value >= min
+	}
+}
+
+// Max rejects numbers above max
+func Max(max float64) Rule[float64] {
+	return func(value float64) (string, string, bool) {
+		return CodeTooLarge, "must be at most " + strconv.FormatFloat(max, 'f', -1, 64), value <= max
+	}
+}
+
+// URL rejects strings that are not absolute http or https URLs.
+// An empty string passes, combine with Required if the URL is mandatory.
+func URL() Rule[string] {
+	return func(value string) (string, string, bool) {
+		if value == "" {
+			return "", "", true
+		}
+
+		parsed, err := url.Parse(value)
+		valid := err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+		return CodeInvalidURL, "must be an absolute http or https URL", valid
+	}
+}
+
+// OneOf rejects strings that are not one of the allowed values
+func OneOf(allowed ...string) Rule[string] {
+	return func(value string) (string, string, bool) {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return "", "", true
+			}
+		}
+		return CodeNotAllowed, "must be one of " + strings.Join(allowed, ", "), false
+	}
+}
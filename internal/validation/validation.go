@@ -0,0 +1,72 @@
+package validation
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Error reports why a single field is invalid
+type Error struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors lists every invalid field of a value
+type Errors []Error
+
+// Error joins the messages of all failures
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Rule checks a value. When the value is invalid it returns false along
+// with an error code and a message that completes the field name,
+// e.g. "is required".
+type Rule[T any] func(value T) (code, message string, ok bool)
+
+// Check validates one field and reports what is wrong with it
+type Check func() Errors
+
+// Field checks a value against its rules in order, stopping at the first
+// rule that fails so each field is reported at most once
+func Field[T any](name string, value T, rules ...Rule[T]) Check {
+	return func() Errors {
+		for _, rule := range rules {
+			if code, message, ok := rule(value); !ok {
+				return Errors{{Field: name, Code: code, Message: name + " " + message}}
+			}
+		}
+		return nil
+	}
+}
+
+// Each checks every item of a list against the rules, naming the failing
+// items by their index, e.g. "duties[2]"
+func Each[T any](name string, values []T, rules ...Rule[T]) Check {
+	return func() Errors {
+		var errs Errors
+		for i, value := range values {
+			errs = append(errs, Field(name+"["+strconv.Itoa(i)+"]", value, rules...)()...)
+		}
+		return errs
+	}
+}
+
+// Validate runs every check and returns the failures as Errors,
+// or nil if all of them passed
+func Validate(checks ...Check) error {
+	var errs Errors
+	for _, check := range checks {
+		errs = append(errs, check()...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
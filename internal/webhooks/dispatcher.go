@@ -0,0 +1,192 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// Dispatcher moves job events from the outbox to subscribers.
+// Each run it queues expired job events, fans pending outbox events out
+// into one delivery per subscriber, and sends every delivery that is due.
+// Failed deliveries are retried with exponential backoff until
+// MaxAttempts is reached.
+type Dispatcher struct {
+	Client       *http.Client
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+}
+
+// NewDispatcher returns a dispatcher with the default settings
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		Client:       NewClient(10 * time.Second),
+		PollInterval: 5 * time.Second,
+		BatchSize:    100,
+		MaxAttempts:  8,
+		BaseDelay:    30 * time.Second,
+		MaxDelay:     6 * time.Hour,
+	}
+}
+
+// Run processes events every PollInterval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.RunOnce(ctx); err != nil {
+			slog.Error("could not dispatch webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce does a single pass over the outbox and the due deliveries
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	if _, err := models.EnqueueExpiredJobEvents(ctx); err != nil {
+		return fmt.Errorf("could not queue expired jobs: %w", err)
+	}
+
+	if err := d.fanOut(ctx); err != nil {
+		return fmt.Errorf("could not fan out events: %w", err)
+	}
+
+	deliveries, err := models.GetDueWebhookDeliveries(ctx, time.Now(), d.BatchSize)
+	if err != nil {
+		return fmt.Errorf("could not fetch due deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		webhook, err := models.GetWebhookByID(ctx, delivery.WebhookID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The webhook was deleted after the delivery was fetched
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not fetch webhook %s: %w", delivery.WebhookID, err)
+		}
+
+		d.deliver(ctx, webhook, &delivery)
+		// Record the outcome even if the dispatcher is stopping meanwhile
+		if err := delivery.RecordAttempt(context.WithoutCancel(ctx)); err != nil {
+			return fmt.Errorf("could not record delivery %s: %w", delivery.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// fanOut turns pending outbox events into deliveries
+func (d *Dispatcher) fanOut(ctx context.Context) error {
+	events, err := models.GetPendingOutboxEvents(ctx, d.BatchSize)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	webhooks, err := models.GetActiveWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err := event.FanOut(ctx, webhooks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliver sends a delivery once and updates its status, attempt count and
+// next attempt time. A 2xx response counts as success.
+func (d *Dispatcher) deliver(ctx context.Context, webhook models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	statusCode, err := d.send(ctx, webhook, delivery)
+	delivery.ResponseStatus = statusCode
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		return
+	case !webhook.Active:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = "webhook is disabled"
+		return
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+	default:
+		delivery.NextAttemptAt = time.Now().Add(d.Backoff(delivery.Attempts)).UTC().Format(models.DateFormat)
+	}
+
+	delivery.LastError = err.Error()
+}
+
+// send POSTs the signed payload to the subscriber
+func (d *Dispatcher) send(ctx context.Context, webhook models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	if !webhook.Active {
+		return 0, errors.New("webhook is disabled")
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "job-board-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(timestamp))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff returns how long to wait before the next attempt, doubling
+// from BaseDelay after every failure and capped at MaxDelay
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	delay := d.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.MaxDelay {
+			return d.MaxDelay
+		}
+	}
+	return delay
+}
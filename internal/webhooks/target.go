@@ -0,0 +1,102 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/validation"
+)
+
+// resolveTimeout bounds the DNS lookup of a target checked by Target
+const resolveTimeout = 5 * time.Second
+
+// errPrivateAddress is returned when a delivery would connect to an
+// address that isn't on the public internet
+var errPrivateAddress = errors.New("webhook target is not a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range, which is as internal
+// as the private ranges
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublic reports whether ip is reachable on the public internet, so the
+// server can't be made to call itself or the network it runs in
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// Target rejects webhook URLs that aren't https or that point at, or
+// resolve to, anything but public addresses. allowPrivate lifts both
+// restrictions for local development.
+func Target(ctx context.Context, allowPrivate bool) validation.Rule[string] {
+	return func(value string) (string, string, bool) {
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Hostname() == "" || (parsed.Scheme != "https" && (!allowPrivate || parsed.Scheme != "http")) {
+			return validation.CodeInvalidURL, "must be an https URL", false
+		}
+
+		if allowPrivate {
+			return "", "", true
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+		defer cancel()
+
+		addresses, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+		if err != nil || len(addresses) == 0 {
+			return validation.CodeInvalidURL, "must have a host that resolves", false
+		}
+		for _, address := range addresses {
+			if !isPublic(address.IP) {
+				return validation.CodeNotAllowed, "must not point at a private address", false
+			}
+		}
+
+		return "", "", true
+	}
+}
+
+// NewClient returns the client deliveries are sent with. It refuses to
+// connect to anything but public addresses, checked on the address
+// actually dialed so a target can't pass Target and then resolve
+// elsewhere, and it doesn't follow redirects.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the target, bypassing the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
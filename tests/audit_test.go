@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// TestJobHistory tests that every change to a job is recorded with a field diff
+func TestJobHistory(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	job := map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+		"url":         "http://example.com/job/1",
+	}
+
+	resp := doAs(t, "alice", "POST", server.URL+"/jobs", job)
+	var created map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	jobID := created["job"].(map[string]interface{})["id"].(string)
+
+	// Change only the salary. Only the owner may.
+	job["salary"] = 150000.0
+	resp = doAs(t, "bob", "PUT", server.URL+"/jobs/"+jobID, job)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected another employer's edit to get status 403, got %d", resp.StatusCode)
+	}
+
+	resp = doAs(t, "alice", "PUT", server.URL+"/jobs/"+jobID, job)
+	resp.Body.Close()
+
+	resp = doAs(t, "alice", "DELETE", server.URL+"/jobs/"+jobID, nil)
+	resp.Body.Close()
+
+	// The history of the deleted draft is only there for its owner
+	anonymousResp, err := http.Get(server.URL + "/jobs/" + jobID + "/history")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	anonymousResp.Body.Close()
+	if anonymousResp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected an anonymous request to get status 404, got %d", anonymousResp.StatusCode)
+	}
+
+	historyResp := doAs(t, "alice", "GET", server.URL+"/jobs/"+jobID+"/history", nil)
+	defer historyResp.Body.Close()
+
+	if historyResp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", historyResp.StatusCode)
+	}
+
+	var history []map[string]interface{}
+	json.NewDecoder(historyResp.Body).Decode(&history)
+
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(history))
+	}
+
+	expected := []struct{ actor, action string }{{"alice", "create"}, {"alice", "update"}, {"alice", "delete"}}
+	for i, want := range expected {
+		if history[i]["actor"] != want.actor || history[i]["action"] != want.action {
+			t.Errorf("Event %d: expected %s by %s, got %v by %v", i, want.action, want.actor, history[i]["action"], history[i]["actor"])
+		}
+	}
+
+	changes := history[1]["changes"].(map[string]interface{})
+	if len(changes) != 1 {
+		t.Errorf("Expected only salary to change, got %v", changes)
+	}
+
+	salary := changes["salary"].(map[string]interface{})
+	if salary["before"] != 120000.0 || salary["after"] != 150000.0 {
+		t.Errorf("Expected salary 120000 -> 150000, got %v", salary)
+	}
+}
+
+// TestRestoreJob tests restoring a deleted job from its history
+func TestRestoreJob(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	// Restoring a job that still exists is a conflict
+	resp := doAs(t, TestEmployer, "POST", server.URL+"/jobs/"+jobID+"/restore", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", resp.StatusCode)
+	}
+
+	resp = doAs(t, TestEmployer, "DELETE", server.URL+"/jobs/"+jobID, nil)
+	resp.Body.Close()
+
+	resp = doAs(t, TestEmployer, "POST", server.URL+"/jobs/"+jobID+"/restore", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var restored map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID, &restored)
+
+	if restored["title"] != "Backend Developer" {
+		t.Errorf("Expected restored title 'Backend Developer', got '%v'", restored["title"])
+	}
+}
+
+// TestAuditLog tests filtering the audit log by actor
+func TestAuditLog(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	job := map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	}
+
+	doAs(t, "alice", "POST", server.URL+"/jobs", job).Body.Close()
+	doAs(t, "bob", "POST", server.URL+"/jobs", job).Body.Close()
+	doAs(t, "bob", "POST", server.URL+"/jobs", job).Body.Close()
+
+	// Only moderators can read the log
+	resp, err := http.Get(server.URL + "/audit?actor=bob")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected an anonymous request to get status 403, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doWithRole(t, "alice", "employer", "GET", server.URL+"/audit?actor=bob", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected an employer to get status 403, got %d", resp.StatusCode)
+	}
+
+	_, result := doWithRole(t, "carol", "reviewer", "GET", server.URL+"/audit?actor=bob", nil)
+
+	metadata := result["metadata"].(map[string]interface{})
+	if metadata["total"] != float64(2) {
+		t.Errorf("Expected 2 events by bob, got %v", metadata["total"])
+	}
+
+	badResp, _ := doWithRole(t, "carol", "reviewer", "GET", server.URL+"/audit?from=yesterday", nil)
+
+	if badResp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid time, got %d", badResp.StatusCode)
+	}
+
+	// The log is append-only
+	if _, err := db.DB.Exec("DELETE FROM job_events"); err == nil {
+		t.Error("Deleting audit entries should fail")
+	}
+}
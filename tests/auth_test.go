@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestAuth_AnonymousWrites tests that jobs can't be created or changed
+// without an identity
+func TestAuth_AnonymousWrites(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	job := map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	}
+	body, _ := json.Marshal(job)
+
+	resp, err := http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected an anonymous create to get status 401, got %d", resp.StatusCode)
+	}
+
+	jobID := createDraftJob(t, server.URL, "alice")
+
+	req, _ := http.NewRequest("DELETE", server.URL+"/jobs/"+jobID, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected an anonymous delete to get status 401, got %d", resp.StatusCode)
+	}
+}
+
+// TestAuth_SpoofedIdentity tests that identity headers are ignored unless
+// they come through the gateway
+func TestAuth_SpoofedIdentity(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+
+	for name, secret := range map[string]string{"no secret": "", "wrong secret": "not-the-gateway-secret"} {
+		// Claiming to be the owner doesn't reveal the draft
+		req, _ := http.NewRequest("GET", server.URL+"/jobs/"+jobID, nil)
+		req.Header.Set("X-Gateway-Secret", secret)
+		req.Header.Set("X-User-ID", "alice")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected a spoofed owner to get status 404, got %d", name, resp.StatusCode)
+		}
+
+		// Claiming a role grants nothing
+		req, _ = http.NewRequest("GET", server.URL+"/moderation/queue", nil)
+		req.Header.Set("X-Gateway-Secret", secret)
+		req.Header.Set("X-User-ID", "mallory")
+		req.Header.Set("X-User-Role", "admin")
+
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: expected a spoofed admin to get status 403, got %d", name, resp.StatusCode)
+		}
+	}
+
+	// The same headers through the gateway are believed
+	resp, _ := doWithRole(t, "alice", "employer", "GET", server.URL+"/jobs/"+jobID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the owner to get status 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/validation"
+)
+
+// envFrom returns a getenv function reading from a map
+func envFrom(env map[string]string) func(string) string {
+	return func(name string) string {
+		return env[name]
+	}
+}
+
+// writeConfigFile writes a config file to a temporary directory
+func writeConfigFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+// TestConfig_Precedence tests that flags beat environment variables, which beat the file, which beats the defaults
+func TestConfig_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"server": {"port": 9000, "host": "127.0.0.1"},
+		"database": {"path": "file.db"},
+		"spam": {"rules_file": "file_rules.json"},
+		"webhooks": {"poll_interval": "2s"},
+		"rate_limit": {"routes": {"POST /jobs": {"requests": 3, "per": "1h"}}}
+	}`)
+
+	env := map[string]string{
+		"CONFIG_FILE":   path,
+		"PORT":          "9100",
+		"DATABASE_PATH": "env.db",
+	}
+
+	cfg, err := config.Load([]string{"-port", "9200"}, envFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Server.Port != 9200 {
+		t.Errorf("Expected the flag to set port 9200, got %d", cfg.Server.Port)
+	}
+	if cfg.Database.Path != "env.db" {
+		t.Errorf("Expected the environment to set the database path, got %q", cfg.Database.Path)
+	}
+	if cfg.Server.Host != "127.0.0.1" || cfg.Spam.RulesFile != "file_rules.json" {
+		t.Errorf("Expected the file to set the host and spam rules, got %q and %q", cfg.Server.Host, cfg.Spam.RulesFile)
+	}
+	if cfg.Webhooks.PollInterval.Duration() != 2*time.Second {
+		t.Errorf("Expected a poll interval of 2s, got %s", cfg.Webhooks.PollInterval)
+	}
+	if cfg.Scheduler.Interval.Duration() != time.Minute {
+		t.Errorf("Expected the default scheduler interval, got %s", cfg.Scheduler.Interval)
+	}
+
+	// The file overrides one route and keeps the other defaults
+	routes := cfg.RateLimit.Limits()
+	if routes["POST /jobs"].Requests != 3 || routes["POST /jobs"].Per != time.Hour {
+		t.Errorf("Expected POST /jobs to be limited to 3 an hour, got %+v", routes["POST /jobs"])
+	}
+	if routes["POST /jobs/import"].Requests != 5 {
+		t.Errorf("Expected the default import limit, got %+v", routes["POST /jobs/import"])
+	}
+
+	// The -config flag beats CONFIG_FILE, and a named file must exist
+	if _, err := config.Load([]string{"-config", filepath.Join(t.TempDir(), "missing.json")}, envFrom(env)); err == nil {
+		t.Errorf("Expected an error for a missing config file")
+	}
+}
+
+// TestConfig_Invalid tests that invalid settings are reported together and unknown ones rejected
+func TestConfig_Invalid(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"server": {"cors_origins": ["example.com"]},
+		"database": {"max_open_conns": 2, "max_idle_conns": 4},
+		"moderation": {"duplicate_policy": "ignore"},
+		"rate_limit": {"store": "redis"},
+		"graphql": {"max_depth": 0},
+		"auth": {"gateway_secret": "short", "api_keys": ["partner-key-0123456789abcdef0123456789", "short"]}
+	}`)
+
+	_, err := config.Load([]string{"-config", path, "-port", "70000"}, envFrom(nil))
+
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected validation errors, got %v", err)
+	}
+
+	codes := map[string]string{}
+	for _, fieldError := range errs {
+		codes[fieldError.Field] = fieldError.Code
+	}
+	expected := map[string]string{
+		"server.port":                 "too_large",
+		"server.cors_origins[0]":      "invalid_url",
+		"database.max_idle_conns":     "too_large",
+		"moderation.duplicate_policy": "not_allowed",
+		"rate_limit.redis_url":        "required",
+		"graphql.max_depth":           "too_small",
+		"auth.gateway_secret":         "too_small",
+		"auth.api_keys[1]":            "too_small",
+	}
+	if len(codes) != len(expected) {
+		t.Errorf("Expected %d errors, got %v", len(expected), codes)
+	}
+	for field, code := range expected {
+		if codes[field] != code {
+			t.Errorf("Expected %s to be %s, got %q", field, code, codes[field])
+		}
+	}
+
+	// The server can't start without an origin to allow
+	path = writeConfigFile(t, `{"server": {"cors_origins": []}}`)
+	_, err = config.Load([]string{"-config", path}, envFrom(nil))
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "server.cors_origins" {
+		t.Errorf("Expected an error naming server.cors_origins, got %v", err)
+	}
+
+	// Misspelled settings are not silently ignored
+	path = writeConfigFile(t, `{"server": {"prot": 9000}}`)
+	if _, err := config.Load([]string{"-config", path}, envFrom(nil)); err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Errorf("Expected an error naming the unknown field, got %v", err)
+	}
+
+	if _, err := config.Load(nil, envFrom(map[string]string{"PORT": "http"})); err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Errorf("Expected an error naming PORT, got %v", err)
+	}
+}
+
+// TestConfig_Redacted tests that secrets are masked when the config is printed
+func TestConfig_Redacted(t *testing.T) {
+	env := map[string]string{
+		"RATE_LIMIT_STORE": "redis",
+		"REDIS_URL":        "redis://:hunter2@cache:6379/0",
+		"GATEWAY_SECRET":   "correct-horse-battery-staple-0123456789",
+		"API_KEYS":         "partner-key-0123456789abcdef0123456789",
+	}
+
+	cfg, err := config.Load(nil, envFrom(env))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	body, _ := json.Marshal(cfg.Redacted())
+	if strings.Contains(string(body), "hunter2") {
+		t.Errorf("Expected the Redis URL to be redacted, got %s", body)
+	}
+	if strings.Contains(string(body), "correct-horse") || strings.Contains(string(body), "partner-key") {
+		t.Errorf("Expected the gateway secret and API keys to be redacted, got %s", body)
+	}
+
+	var printed map[string]interface{}
+	json.Unmarshal(body, &printed)
+	rateLimit := printed["rate_limit"].(map[string]interface{})
+	if rateLimit["redis_url"] != "[REDACTED]" || rateLimit["store"] != "redis" {
+		t.Errorf("Expected only the Redis URL to be redacted, got %v", rateLimit)
+	}
+	if printed["scheduler"].(map[string]interface{})["interval"] != "1m0s" {
+		t.Errorf("Expected durations to print as strings, got %v", printed["scheduler"])
+	}
+
+	// The original keeps its secret
+	if cfg.RateLimit.RedisURL != env["REDIS_URL"] || cfg.Auth.APIKeys[0] != env["API_KEYS"] {
+		t.Errorf("Expected Redacted not to change the config")
+	}
+}
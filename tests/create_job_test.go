@@ -22,7 +22,7 @@ func TestCreateJob(t *testing.T) {
 	}
 
 	body, _ := json.Marshal(job)
-	resp, err := http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	resp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Request failed: %v", err)
 	}
@@ -65,13 +65,26 @@ func TestCreateJob_MissingTitle(t *testing.T) {
 	}
 
 	body, _ := json.Marshal(job)
-	resp, err := http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	resp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	fieldErrors := result["errors"].([]interface{})
+	if len(fieldErrors) != 1 {
+		t.Fatalf("Expected 1 field error, got %d", len(fieldErrors))
+	}
+
+	fieldError := fieldErrors[0].(map[string]interface{})
+	if fieldError["field"] != "title" || fieldError["code"] != "required" {
+		t.Errorf("Expected a required error on title, got %v", fieldError)
 	}
 }
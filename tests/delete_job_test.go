@@ -23,7 +23,7 @@ func TestDeleteJob(t *testing.T) {
 	}
 
 	body, _ := json.Marshal(job)
-	createResp, err := http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	createResp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create job: %v", err)
 	}
@@ -37,6 +37,7 @@ func TestDeleteJob(t *testing.T) {
 
 	// Delete the job
 	req, _ := http.NewRequest("DELETE", server.URL+"/jobs/"+jobID, nil)
+	Authenticate(req, TestEmployer, "employer")
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
@@ -75,6 +76,7 @@ func TestDeleteJob_NotFound(t *testing.T) {
 
 	// Try to delete non-existent job
 	req, _ := http.NewRequest("DELETE", server.URL+"/jobs/invalid-id", nil)
+	Authenticate(req, TestEmployer, "employer")
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
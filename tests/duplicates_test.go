@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// duplicateJob returns a job posting with the given title and description
+func duplicateJob(title, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       title,
+		"description": description,
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	}
+}
+
+const dataDescription = "Design and run the data pipelines that feed our reporting warehouse, using Python, SQL and Airflow every day."
+
+const backendDescription = "We are looking for a backend developer to build scalable APIs in Go and maintain our payment services."
+
+// TestDuplicates_Warn tests that a near-duplicate from the same owner is flagged
+func TestDuplicates_Warn(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	_, first := doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend Developer", backendDescription))
+	firstID := first["job"].(map[string]interface{})["id"].(string)
+
+	// Same posting with a small edit
+	resp, second := doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend developer!", backendDescription+" Apply now."))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	duplicates, _ := second["job"].(map[string]interface{})["possible_duplicates"].([]interface{})
+	if len(duplicates) != 1 || duplicates[0] != firstID {
+		t.Errorf("Expected the job to be flagged as a duplicate of %s, got %v", firstID, duplicates)
+	}
+
+	// Another owner may post the same job
+	_, other := doWithRole(t, "bob", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend Developer", backendDescription))
+	if _, ok := other["job"].(map[string]interface{})["possible_duplicates"]; ok {
+		t.Errorf("Expected no duplicates across owners, got %v", other["job"])
+	}
+
+	// An unrelated job isn't flagged
+	_, unrelated := doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs",
+		duplicateJob("Frontend Designer", "Design beautiful user interfaces in Figma and React for our marketing website."))
+	if _, ok := unrelated["job"].(map[string]interface{})["possible_duplicates"]; ok {
+		t.Errorf("Expected an unrelated job not to be flagged, got %v", unrelated["job"])
+	}
+}
+
+// TestDuplicates_Reject tests that near-duplicates are refused when the policy says so
+func TestDuplicates_Reject(t *testing.T) {
+	server := SetupTestAppWith(t, func(cfg *config.Config) {
+		cfg.Moderation.DuplicatePolicy = models.DuplicateReject
+	})
+	defer Teardown(t, server)
+
+	doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend Developer", backendDescription))
+
+	resp, result := doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend Developer", backendDescription))
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected status 409, got %d", resp.StatusCode)
+	}
+
+	if len(result["duplicates"].([]interface{})) != 1 {
+		t.Errorf("Expected the duplicated job to be named, got %v", result["duplicates"])
+	}
+}
+
+// TestDuplicates_Clusters tests the admin listing of suspected duplicates
+func TestDuplicates_Clusters(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend Developer", backendDescription))
+	doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend Developer", backendDescription+" Apply now."))
+	doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs",
+		duplicateJob("Frontend Designer", "Design beautiful user interfaces in Figma and React for our marketing website."))
+	doWithRole(t, "bob", "employer", "POST", server.URL+"/jobs", duplicateJob("Backend Developer", backendDescription))
+
+	// Look-alikes posted before the duplicate window or without an owner
+	// aren't listed
+	for _, owner := range []string{"alice", "alice", "carol", "carol"} {
+		doWithRole(t, owner, "employer", "POST", server.URL+"/jobs", duplicateJob("Data Engineer "+owner, dataDescription))
+	}
+	if _, err := db.DB.Exec("UPDATE jobs SET created_at = datetime('now', '-40 days') WHERE owner_id = 'alice' AND title LIKE 'Data Engineer%'"); err != nil {
+		t.Fatalf("Failed to age jobs: %v", err)
+	}
+	if _, err := db.DB.Exec("UPDATE jobs SET owner_id = '' WHERE owner_id = 'carol'"); err != nil {
+		t.Fatalf("Failed to disown jobs: %v", err)
+	}
+
+	resp, _ := doWithRole(t, "alice", "employer", "GET", server.URL+"/admin/duplicates", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for an employer, got %d", resp.StatusCode)
+	}
+
+	resp, result := doWithRole(t, "root", "admin", "GET", server.URL+"/admin/duplicates", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	clusters := result["data"].([]interface{})
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(clusters))
+	}
+
+	cluster := clusters[0].(map[string]interface{})
+	if cluster["owner_id"] != "alice" || len(cluster["jobs"].([]interface{})) != 2 {
+		t.Errorf("Expected alice's two backend jobs in the cluster, got %v", cluster)
+	}
+}
@@ -0,0 +1,240 @@
+package tests
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// createExportJobs creates and publishes the jobs used by the export tests
+func createExportJobs(t *testing.T, serverURL string) {
+	jobs := []map[string]interface{}{
+		{
+			"title":       "Backend Developer",
+			"description": "Build APIs",
+			"location":    "Lagos",
+			"salary":      120000.0,
+			"duties":      []string{"Write code", "Review PRs"},
+			"url":         "http://example.com/1",
+		},
+		{
+			"title":       "Frontend Developer",
+			"description": "Build UIs",
+			"location":    "Remote",
+			"salary":      100000.0,
+			"duties":      []string{"Design"},
+			"url":         "http://example.com/2",
+		},
+	}
+
+	for _, job := range jobs {
+		body, _ := json.Marshal(job)
+		resp, err := Post(serverURL+"/jobs", "application/json", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+
+		var created map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&created)
+		resp.Body.Close()
+
+		PublishJob(t, serverURL, created["job"].(map[string]interface{})["id"].(string))
+	}
+}
+
+// TestExportJobs_CSV tests exporting filtered jobs as CSV
+func TestExportJobs_CSV(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createExportJobs(t, server.URL)
+
+	resp, err := http.Get(server.URL + "/jobs/export?format=csv&query=backend")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	disposition := resp.Header.Get("Content-Disposition")
+	if !strings.HasPrefix(disposition, "attachment;") || !strings.Contains(disposition, ".csv") {
+		t.Errorf("Unexpected Content-Disposition '%s'", disposition)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+
+	// Header row plus the single matching job
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(records))
+	}
+
+	if records[1][1] != "Backend Developer" {
+		t.Errorf("Expected title 'Backend Developer', got '%s'", records[1][1])
+	}
+
+	if records[1][5] != "Write code; Review PRs" {
+		t.Errorf("Expected flattened duties, got '%s'", records[1][5])
+	}
+}
+
+// TestExportJobs_NDJSON tests exporting jobs as NDJSON
+func TestExportJobs_NDJSON(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createExportJobs(t, server.URL)
+
+	resp, err := http.Get(server.URL + "/jobs/export?format=ndjson")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var job map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &job); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v", lines+1, err)
+		}
+		lines++
+	}
+
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+}
+
+// TestExportJobs_XLSX tests exporting jobs as an Excel workbook
+func TestExportJobs_XLSX(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createExportJobs(t, server.URL)
+
+	resp, err := http.Get(server.URL + "/jobs/export?format=xlsx")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Export is not a valid zip archive: %v", err)
+	}
+
+	var sheet []byte
+	for _, f := range archive.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			sheet, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+
+	if sheet == nil {
+		t.Fatal("Workbook has no sheet")
+	}
+
+	// Header row plus two jobs
+	if rows := strings.Count(string(sheet), "<row "); rows != 3 {
+		t.Errorf("Expected 3 rows, got %d", rows)
+	}
+}
+
+// TestExportJobs_Formulas tests that cells a spreadsheet would run as
+// formulas are exported as text
+func TestExportJobs_Formulas(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"title":       "=HYPERLINK(\"http://evil.example\", \"Apply\")",
+		"description": "+1 year of Go",
+		"location":    "@Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"-Write code"},
+	})
+	resp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	var created map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	PublishJob(t, server.URL, created["job"].(map[string]interface{})["id"].(string))
+
+	resp, err = http.Get(server.URL + "/jobs/export?format=csv")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	resp.Body.Close()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Expected a header and one job, got %v and %v", records, err)
+	}
+
+	expected := map[int]string{
+		1: `'=HYPERLINK("http://evil.example", "Apply")`,
+		2: "'+1 year of Go",
+		3: "'@Lagos",
+		4: "120000",
+		5: "'-Write code",
+	}
+	for column, cell := range expected {
+		if records[1][column] != cell {
+			t.Errorf("Expected %s to be %q, got %q", records[0][column], cell, records[1][column])
+		}
+	}
+
+	// Workbooks keep the text as it is, in cells typed as text
+	resp, err = http.Get(server.URL + "/jobs/export?format=xlsx")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	workbook, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	archive, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("Export is not a valid zip archive: %v", err)
+	}
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		sheet, _ := io.ReadAll(rc)
+		rc.Close()
+		if !strings.Contains(string(sheet), `<c t="inlineStr"><is><t xml:space="preserve">+1 year of Go</t></is></c>`) {
+			t.Errorf("Expected the description in a text cell, got %s", sheet)
+		}
+	}
+}
+
+func TestExportJobs_UnsupportedFormat(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	resp, err := http.Get(server.URL + "/jobs/export?format=pdf")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// createFeedJob creates and publishes a job and returns its ID
+func createFeedJob(t *testing.T, serverURL, title string) string {
+	job := map[string]interface{}{
+		"title":       title,
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+		"url":         "http://example.com/job/1",
+	}
+
+	body, _ := json.Marshal(job)
+	resp, err := Post(serverURL+"/jobs", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	jobID := result["job"].(map[string]interface{})["id"].(string)
+
+	PublishJob(t, serverURL, jobID)
+	return jobID
+}
+
+// TestRSSFeed tests the RSS feed of recent jobs
+func TestRSSFeed(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+	createFeedJob(t, server.URL, "Frontend Developer")
+
+	resp, err := http.Get(server.URL + "/jobs/feed.rss?query=backend")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/rss+xml") {
+		t.Errorf("Unexpected Content-Type '%s'", resp.Header.Get("Content-Type"))
+	}
+
+	var rss struct {
+		Items []struct {
+			Title   string `xml:"title"`
+			Link    string `xml:"link"`
+			GUID    string `xml:"guid"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
+		t.Fatalf("Failed to parse RSS: %v", err)
+	}
+
+	if len(rss.Items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(rss.Items))
+	}
+
+	item := rss.Items[0]
+	if item.GUID != jobID {
+		t.Errorf("Expected GUID %s, got %s", jobID, item.GUID)
+	}
+
+	if !strings.HasSuffix(item.Link, "/job/"+jobID) {
+		t.Errorf("Expected link to the job details page, got %s", item.Link)
+	}
+
+	if item.PubDate == "" {
+		t.Error("Item should have a pubDate")
+	}
+}
+
+// TestAtomFeed tests the Atom feed of recent jobs
+func TestAtomFeed(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	resp, err := http.Get(server.URL + "/jobs/feed.atom")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var atom struct {
+		Entries []struct {
+			ID      string `xml:"id"`
+			Updated string `xml:"updated"`
+		} `xml:"entry"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&atom); err != nil {
+		t.Fatalf("Failed to parse Atom: %v", err)
+	}
+
+	if len(atom.Entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(atom.Entries))
+	}
+
+	if atom.Entries[0].ID != "urn:uuid:"+jobID {
+		t.Errorf("Expected entry ID urn:uuid:%s, got %s", jobID, atom.Entries[0].ID)
+	}
+
+	if atom.Entries[0].Updated == "" {
+		t.Error("Entry should have an updated date")
+	}
+}
+
+// TestFeed_NotModified tests conditional GET on the feeds
+func TestFeed_NotModified(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createFeedJob(t, server.URL, "Backend Developer")
+
+	resp, err := http.Get(server.URL + "/jobs/feed.rss")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	lastModified := resp.Header.Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("Feed should send Last-Modified")
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/jobs/feed.rss", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+
+	client := &http.Client{}
+	conditionalResp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer conditionalResp.Body.Close()
+
+	if conditionalResp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected status 304, got %d", conditionalResp.StatusCode)
+	}
+}
+
+// TestFeed_LastModifiedAfterEdit tests that editing a job in the feed
+// moves its Last-Modified on
+func TestFeed_LastModifiedAfterEdit(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+	if _, err := db.DB.Exec("UPDATE jobs SET published_at = ?", time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)); err != nil {
+		t.Fatalf("Failed to backdate job: %v", err)
+	}
+	published := feedLastModified(t, server.URL)
+
+	// The edit sends the job back to review, from which it is approved
+	// again without being published anew
+	resp, _ := doWithRole(t, TestEmployer, "employer", "PUT", server.URL+"/jobs/"+jobID, map[string]interface{}{
+		"title":       "Senior Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      150000.0,
+		"duties":      []string{"Write code"},
+		"url":         "http://example.com/job/1",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to edit job, got status %d", resp.StatusCode)
+	}
+	if resp, _ := doWithRole(t, "root", "admin", "POST", server.URL+"/jobs/"+jobID+"/approve", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to approve job, got status %d", resp.StatusCode)
+	}
+
+	if edited := feedLastModified(t, server.URL); !edited.After(published) {
+		t.Errorf("Expected Last-Modified to move on from %s after the edit, got %s", published, edited)
+	}
+}
+
+// feedLastModified returns the Last-Modified time of the RSS feed
+func feedLastModified(t *testing.T, serverURL string) time.Time {
+	resp, err := http.Get(serverURL + "/jobs/feed.rss")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		t.Fatalf("Feed should send Last-Modified, got %v", err)
+	}
+	return lastModified
+}
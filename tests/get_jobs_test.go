@@ -31,12 +31,20 @@ func TestGetAllJobs(t *testing.T) {
 		"url":         "http://example.com/2",
 	}
 
-	// Create jobs via API
-	body1, _ := json.Marshal(job1)
-	http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body1))
+	// Create and publish jobs via API
+	for _, job := range []map[string]interface{}{job1, job2} {
+		body, _ := json.Marshal(job)
+		createResp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
 
-	body2, _ := json.Marshal(job2)
-	http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body2))
+		var created map[string]interface{}
+		json.NewDecoder(createResp.Body).Decode(&created)
+		createResp.Body.Close()
+
+		PublishJob(t, server.URL, created["job"].(map[string]interface{})["id"].(string))
+	}
 
 	// Fetch all jobs
 	resp, err := http.Get(server.URL + "/jobs")
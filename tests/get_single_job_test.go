@@ -23,7 +23,7 @@ func TestGetSingleJob(t *testing.T) {
 	}
 
 	body, _ := json.Marshal(job)
-	createResp, err := http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	createResp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create job: %v", err)
 	}
@@ -34,6 +34,7 @@ func TestGetSingleJob(t *testing.T) {
 	json.NewDecoder(createResp.Body).Decode(&createResult)
 	jobData := createResult["job"].(map[string]interface{})
 	jobID := jobData["id"].(string)
+	PublishJob(t, server.URL, jobID)
 
 	// Fetch the single job
 	resp, err := http.Get(server.URL + "/jobs/" + jobID)
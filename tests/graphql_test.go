@@ -0,0 +1,395 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// postGraphQL runs a GraphQL request as the given user and returns the result
+func postGraphQL(t *testing.T, serverURL, user, query string, variables map[string]interface{}) map[string]interface{} {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+
+	req, _ := http.NewRequest("POST", serverURL+"/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		Authenticate(req, user, "employer")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return result
+}
+
+// graphQLErrorCode returns the code of the first error in a result
+func graphQLErrorCode(result map[string]interface{}) string {
+	errs, _ := result["errors"].([]interface{})
+	if len(errs) == 0 {
+		return ""
+	}
+	extensions, _ := errs[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	code, _ := extensions["code"].(string)
+	return code
+}
+
+// createListedJob posts a job as owner in a location and publishes it
+func createListedJob(t *testing.T, serverURL, owner, title, location string, salary float64) string {
+	job := map[string]interface{}{
+		"title":       title,
+		"description": "Work on " + title,
+		"location":    location,
+		"salary":      salary,
+		"duties":      []string{"Write code"},
+	}
+
+	_, result := doWithRole(t, owner, "employer", "POST", serverURL+"/jobs", job)
+	jobID := result["job"].(map[string]interface{})["id"].(string)
+
+	PublishJob(t, serverURL, jobID)
+	return jobID
+}
+
+// TestGraphQL_Job tests fetching a job with its company and related jobs
+func TestGraphQL_Job(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createListedJob(t, server.URL, "acme", "Backend Developer", "Lagos", 120000)
+	relatedID := createListedJob(t, server.URL, "globex", "Frontend Developer", "Lagos", 90000)
+	createListedJob(t, server.URL, "globex", "Data Engineer", "Abuja", 150000)
+
+	result := postGraphQL(t, server.URL, "", `query($id: ID!) {
+		job(id: $id) {
+			id title location salary duties
+			company { id jobs { totalCount } }
+			relatedJobs { id }
+		}
+	}`, map[string]interface{}{"id": jobID})
+
+	if result["errors"] != nil {
+		t.Fatalf("Expected no errors, got %v", result["errors"])
+	}
+
+	job := result["data"].(map[string]interface{})["job"].(map[string]interface{})
+	if job["id"] != jobID || job["title"] != "Backend Developer" || job["salary"] != 120000.0 {
+		t.Errorf("Expected the job, got %v", job)
+	}
+
+	company := job["company"].(map[string]interface{})
+	if company["id"] != "acme" || company["jobs"].(map[string]interface{})["totalCount"] != 1.0 {
+		t.Errorf("Expected the company that posted the job, got %v", company)
+	}
+
+	related := job["relatedJobs"].([]interface{})
+	if len(related) != 1 || related[0].(map[string]interface{})["id"] != relatedID {
+		t.Errorf("Expected the other job in Lagos to be related, got %v", related)
+	}
+}
+
+// TestGraphQL_JobNotVisible tests that drafts of other users are null
+func TestGraphQL_JobNotVisible(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+
+	query := `query($id: ID!) { job(id: $id) { id } }`
+
+	result := postGraphQL(t, server.URL, "bob", query, map[string]interface{}{"id": jobID})
+	if result["data"].(map[string]interface{})["job"] != nil {
+		t.Errorf("Expected another employer's draft to be null, got %v", result["data"])
+	}
+
+	result = postGraphQL(t, server.URL, "alice", query, map[string]interface{}{"id": jobID})
+	if result["data"].(map[string]interface{})["job"] == nil {
+		t.Errorf("Expected the owner to see their draft, got %v", result)
+	}
+}
+
+// TestGraphQL_JobsPagination tests paging through jobs with cursors
+func TestGraphQL_JobsPagination(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createListedJob(t, server.URL, "acme", "Backend Developer", "Lagos", 100000)
+	createListedJob(t, server.URL, "acme", "Frontend Developer", "Lagos", 300000)
+	createListedJob(t, server.URL, "acme", "Data Developer", "Lagos", 200000)
+	createListedJob(t, server.URL, "acme", "Product Manager", "Lagos", 400000)
+
+	query := `query($after: String) {
+		jobs(query: "developer", sort: HIGHEST_SALARY, first: 2, after: $after) {
+			totalCount
+			edges { cursor node { title salary } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	var titles []string
+	var after interface{}
+	for pages := 0; pages < 3; pages++ {
+		result := postGraphQL(t, server.URL, "", query, map[string]interface{}{"after": after})
+		if result["errors"] != nil {
+			t.Fatalf("Expected no errors, got %v", result["errors"])
+		}
+
+		jobs := result["data"].(map[string]interface{})["jobs"].(map[string]interface{})
+		if jobs["totalCount"] != 3.0 {
+			t.Errorf("Expected 3 matching jobs, got %v", jobs["totalCount"])
+		}
+		for _, edge := range jobs["edges"].([]interface{}) {
+			titles = append(titles, edge.(map[string]interface{})["node"].(map[string]interface{})["title"].(string))
+		}
+
+		pageInfo := jobs["pageInfo"].(map[string]interface{})
+		if pageInfo["hasNextPage"] != true {
+			break
+		}
+		after = pageInfo["endCursor"]
+	}
+
+	expected := "Frontend Developer, Data Developer, Backend Developer"
+	if strings.Join(titles, ", ") != expected {
+		t.Errorf("Expected %s, got %v", expected, titles)
+	}
+}
+
+// TestGraphQL_InvalidArguments tests that bad paging arguments are reported
+func TestGraphQL_InvalidArguments(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	result := postGraphQL(t, server.URL, "", `{ jobs(after: "nonsense") { totalCount } }`, nil)
+	if code := graphQLErrorCode(result); code != "BAD_USER_INPUT" {
+		t.Errorf("Expected BAD_USER_INPUT for an invalid cursor, got %v", result)
+	}
+
+	result = postGraphQL(t, server.URL, "", `{ jobs(first: 0) { totalCount } }`, nil)
+	if code := graphQLErrorCode(result); code != "BAD_USER_INPUT" {
+		t.Errorf("Expected BAD_USER_INPUT for an empty page, got %v", result)
+	}
+}
+
+// TestGraphQL_Mutations tests creating, updating and deleting a job
+func TestGraphQL_Mutations(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	input := map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build **APIs**",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	}
+
+	result := postGraphQL(t, server.URL, "alice", `mutation($input: JobInput!) {
+		createJob(input: $input) { id status descriptionHtml company { id } }
+	}`, map[string]interface{}{"input": input})
+	if result["errors"] != nil {
+		t.Fatalf("Expected no errors, got %v", result["errors"])
+	}
+
+	created := result["data"].(map[string]interface{})["createJob"].(map[string]interface{})
+	jobID := created["id"].(string)
+	if created["status"] != "draft" || created["company"].(map[string]interface{})["id"] != "alice" {
+		t.Errorf("Expected a draft owned by alice, got %v", created)
+	}
+	if !strings.Contains(created["descriptionHtml"].(string), "<strong>APIs</strong>") {
+		t.Errorf("Expected the description rendered to HTML, got %v", created["descriptionHtml"])
+	}
+
+	input["title"] = "Senior Backend Developer"
+	result = postGraphQL(t, server.URL, "alice", `mutation($id: ID!, $input: JobInput!) {
+		updateJob(id: $id, input: $input) { title }
+	}`, map[string]interface{}{"id": jobID, "input": input})
+
+	updated := result["data"].(map[string]interface{})["updateJob"].(map[string]interface{})
+	if updated["title"] != "Senior Backend Developer" {
+		t.Errorf("Expected the title to be updated, got %v", result)
+	}
+
+	result = postGraphQL(t, server.URL, "bob", `mutation($id: ID!) { deleteJob(id: $id) }`, map[string]interface{}{"id": jobID})
+	if code := graphQLErrorCode(result); code != "NOT_FOUND" {
+		t.Errorf("Expected another employer not to find the draft, got %v", result)
+	}
+
+	result = postGraphQL(t, server.URL, "alice", `mutation($id: ID!) { deleteJob(id: $id) }`, map[string]interface{}{"id": jobID})
+	if result["data"].(map[string]interface{})["deleteJob"] != jobID {
+		t.Errorf("Expected the job to be deleted, got %v", result)
+	}
+
+	var job map[string]interface{}
+	if status := getJSON(t, server.URL+"/api/v1/jobs/"+jobID, &job); status == http.StatusOK {
+		t.Errorf("Expected the deleted job to be gone, got status %d", status)
+	}
+}
+
+// TestGraphQL_MutationsOwnership tests that only the owner can change a
+// published job, and that their edits are reviewed like REST edits
+func TestGraphQL_MutationsOwnership(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createListedJob(t, server.URL, "alice", "Backend Developer", "Lagos", 120000)
+	input := map[string]interface{}{
+		"title":       "Backend Developer (apply elsewhere)",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	}
+
+	update := `mutation($id: ID!, $input: JobInput!) { updateJob(id: $id, input: $input) { title status } }`
+	result := postGraphQL(t, server.URL, "bob", update, map[string]interface{}{"id": jobID, "input": input})
+	if code := graphQLErrorCode(result); code != "FORBIDDEN" {
+		t.Errorf("Expected another employer to be forbidden to edit the job, got %v", result)
+	}
+
+	result = postGraphQL(t, server.URL, "bob", `mutation($id: ID!) { deleteJob(id: $id) }`, map[string]interface{}{"id": jobID})
+	if code := graphQLErrorCode(result); code != "FORBIDDEN" {
+		t.Errorf("Expected another employer to be forbidden to delete the job, got %v", result)
+	}
+
+	var job map[string]interface{}
+	if status := getJSON(t, server.URL+"/api/v1/jobs/"+jobID, &job); status != http.StatusOK || job["title"] != "Backend Developer" {
+		t.Fatalf("Expected the job to be left as it was, got %d %v", status, job)
+	}
+
+	// The owner's edit goes back to review
+	input["title"] = "Senior Backend Developer"
+	result = postGraphQL(t, server.URL, "alice", update, map[string]interface{}{"id": jobID, "input": input})
+	if updated := result["data"].(map[string]interface{})["updateJob"].(map[string]interface{}); updated["status"] != "pending_review" {
+		t.Errorf("Expected the edited job to go back to review, got %v", result)
+	}
+
+	// and one that looks like spam is held
+	spam := map[string]interface{}{
+		"title":       "EARN MONEY FAST FROM HOME TODAY",
+		"description": "Pay the registration fee by wire transfer to start.",
+		"location":    "Remote",
+		"salary":      120000.0,
+		"duties":      []string{"Send money"},
+		"url":         "https://bit.ly/abc",
+	}
+	result = postGraphQL(t, server.URL, "alice", update, map[string]interface{}{"id": jobID, "input": spam})
+	if updated := result["data"].(map[string]interface{})["updateJob"].(map[string]interface{}); updated["status"] != "held" {
+		t.Errorf("Expected the spammy edit to be held, got %v", result)
+	}
+}
+
+// TestGraphQL_ValidationError tests that invalid input lists the invalid fields
+func TestGraphQL_ValidationError(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	input := map[string]interface{}{"title": "", "description": "Build APIs", "location": "Lagos", "salary": 1000.0, "duties": []string{"Write code"}}
+	result := postGraphQL(t, server.URL, "alice", `mutation($input: JobInput!) { createJob(input: $input) { id } }`, map[string]interface{}{"input": input})
+
+	if code := graphQLErrorCode(result); code != "BAD_USER_INPUT" {
+		t.Fatalf("Expected BAD_USER_INPUT, got %v", result)
+	}
+
+	extensions := result["errors"].([]interface{})[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	fieldErrors, _ := extensions["errors"].([]interface{})
+	if len(fieldErrors) != 1 || fieldErrors[0].(map[string]interface{})["field"] != "title" {
+		t.Errorf("Expected the missing title to be listed, got %v", extensions)
+	}
+}
+
+// TestGraphQL_Limits tests that queries too deep or too complex are rejected
+func TestGraphQL_Limits(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	// Eleven levels of related jobs, one more than the default limit
+	deep := `{ jobs { edges { node { ` + strings.Repeat("relatedJobs { ", 7) + "id" + strings.Repeat(" }", 7) + ` } } } }`
+	result := postGraphQL(t, server.URL, "", deep, nil)
+	if code := graphQLErrorCode(result); code != "QUERY_TOO_DEEP" {
+		t.Errorf("Expected QUERY_TOO_DEEP, got %v", result)
+	}
+	if result["data"] != nil {
+		t.Errorf("Expected nothing to be resolved, got %v", result["data"])
+	}
+
+	// 100 jobs with 20 related jobs each
+	wide := `query($first: Int) { jobs(first: $first) { edges { node { relatedJobs(first: 20) { id } } } } }`
+	result = postGraphQL(t, server.URL, "", wide, map[string]interface{}{"first": 100})
+	if code := graphQLErrorCode(result); code != "QUERY_TOO_COMPLEX" {
+		t.Errorf("Expected QUERY_TOO_COMPLEX, got %v", result)
+	}
+
+	// A default counts when the variable is left out
+	defaulted := `query($first: Int = 100) { jobs(first: $first) { edges { node { relatedJobs(first: 20) { id } } } } }`
+	result = postGraphQL(t, server.URL, "", defaulted, nil)
+	if code := graphQLErrorCode(result); code != "QUERY_TOO_COMPLEX" {
+		t.Errorf("Expected QUERY_TOO_COMPLEX for a defaulted first, got %v", result)
+	}
+
+	result = postGraphQL(t, server.URL, "", wide, map[string]interface{}{"first": 10})
+	if result["errors"] != nil {
+		t.Errorf("Expected a smaller page to be allowed, got %v", result["errors"])
+	}
+
+	// Introspection doesn't count towards the limits
+	result = postGraphQL(t, server.URL, "", `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil)
+	if result["errors"] != nil {
+		t.Errorf("Expected introspection to be allowed, got %v", result["errors"])
+	}
+}
+
+// TestGraphQL_Batching tests that companies and related jobs are loaded
+// with one query for every job listed
+func TestGraphQL_Batching(t *testing.T) {
+	recorder := recordSpans(t)
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	for _, owner := range []string{"acme", "globex", "initech"} {
+		createListedJob(t, server.URL, owner, "Developer at "+owner, "Lagos", 100000)
+	}
+
+	result := postGraphQL(t, server.URL, "", `{
+		jobs {
+			edges { node { company { id jobs { totalCount } } relatedJobs { id } } }
+		}
+	}`, nil)
+	if result["errors"] != nil {
+		t.Fatalf("Expected no errors, got %v", result["errors"])
+	}
+
+	for _, edge := range result["data"].(map[string]interface{})["jobs"].(map[string]interface{})["edges"].([]interface{}) {
+		node := edge.(map[string]interface{})["node"].(map[string]interface{})
+		if total := node["company"].(map[string]interface{})["jobs"].(map[string]interface{})["totalCount"]; total != 1.0 {
+			t.Errorf("Expected each company to have 1 job, got %v", total)
+		}
+		if related := node["relatedJobs"].([]interface{}); len(related) != 2 {
+			t.Errorf("Expected the 2 other jobs in Lagos to be related, got %v", related)
+		}
+	}
+
+	byOwner, byLocation := 0, 0
+	for _, span := range recorder.Ended() {
+		query := spanAttribute(span, "db.statement")
+		if strings.Contains(query, "PARTITION BY owner_id") {
+			byOwner++
+		}
+		if strings.Contains(query, "PARTITION BY location") {
+			byLocation++
+		}
+	}
+	if byOwner != 1 || byLocation != 1 {
+		t.Errorf("Expected one query for the companies' jobs and one for related jobs, got %d and %d", byOwner, byLocation)
+	}
+}
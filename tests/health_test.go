@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/handlers"
+	"github.com/Ademayowa/job-board/internal/server"
+)
+
+// TestHealth tests the liveness and version endpoints
+func TestHealth(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	result := map[string]interface{}{}
+	status := getJSON(t, server.URL+"/healthz", &result)
+	if status != http.StatusOK || result["status"] != "ok" {
+		t.Errorf("Expected status 200 and ok, got %d and %v", status, result)
+	}
+
+	result = map[string]interface{}{}
+	status = getJSON(t, server.URL+"/version", &result)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if result["version"] != "dev" || result["go_version"] != runtime.Version() || result["commit"] == "" {
+		t.Errorf("Expected the build info of a dev build, got %v", result)
+	}
+}
+
+// TestReadiness tests that readiness fails while migrations are pending or the server is shutting down
+func TestReadiness(t *testing.T) {
+	var shuttingDown atomic.Bool
+	server := SetupTestAppServing(t, nil, handlers.Dependencies{ShuttingDown: &shuttingDown})
+	defer Teardown(t, server)
+
+	result := map[string]interface{}{}
+	status := getJSON(t, server.URL+"/readyz", &result)
+	if status != http.StatusOK || result["status"] != "ready" {
+		t.Fatalf("Expected status 200 and ready, got %d and %v", status, result)
+	}
+
+	// A server running against an older schema isn't ready
+	if _, err := db.DB.Exec("DELETE FROM schema_migrations WHERE version = (SELECT MAX(version) FROM schema_migrations)"); err != nil {
+		t.Fatalf("Failed to remove migration: %v", err)
+	}
+
+	result = map[string]interface{}{}
+	status = getJSON(t, server.URL+"/readyz", &result)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", status)
+	}
+	if checks := result["checks"].(map[string]interface{}); checks["migrations"] != "1 pending" || checks["database"] != "ok" {
+		t.Errorf("Expected one pending migration, got %v", checks)
+	}
+
+	pending, err := db.PendingMigrations(context.Background())
+	if err != nil || len(pending) != 1 {
+		t.Errorf("Expected one pending migration, got %v and %v", pending, err)
+	}
+
+	// Readiness fails as soon as shutdown starts, while liveness holds
+	shuttingDown.Store(true)
+
+	result = map[string]interface{}{}
+	status = getJSON(t, server.URL+"/readyz", &result)
+	if status != http.StatusServiceUnavailable || result["status"] != "shutting down" {
+		t.Errorf("Expected status 503 while shutting down, got %d and %v", status, result)
+	}
+	if status := getJSON(t, server.URL+"/healthz", &result); status != http.StatusOK {
+		t.Errorf("Expected liveness to pass while shutting down, got %d", status)
+	}
+}
+
+// TestReadiness_DatabaseDown tests that readiness fails without telling
+// the client why when the database can't be reached
+func TestReadiness_DatabaseDown(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	db.DB.Close()
+
+	result := map[string]interface{}{}
+	status := getJSON(t, server.URL+"/readyz", &result)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", status)
+	}
+	if checks := result["checks"].(map[string]interface{}); checks["database"] != "failed" || checks["migrations"] != "unknown" {
+		t.Errorf("Expected the database check to fail without details, got %v", checks)
+	}
+}
+
+// TestDrain tests that the server keeps serving for the drain delay after it is told to stop
+func TestDrain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	notified := make(chan time.Time, 1)
+
+	drained := server.Drain(ctx, 100*time.Millisecond, func() {
+		notified <- time.Now()
+	})
+
+	select {
+	case <-drained.Done():
+		t.Fatalf("Expected the server to keep serving before it is told to stop")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel()
+	notifiedAt := <-notified
+	<-drained.Done()
+
+	if elapsed := time.Since(notifiedAt); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected to drain for 100ms after notifying, drained after %s", elapsed)
+	}
+}
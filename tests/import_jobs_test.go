@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/models"
+)
+
+// TestImportJobs_CSV tests importing jobs from a CSV file with a column mapping
+func TestImportJobs_CSV(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	csvData := "Job Title,description,location,salary,duties,url\n" +
+		"Backend Developer,Build APIs,Lagos,120000,Write code; Review PRs,http://example.com/1\n" +
+		"Frontend Developer,Build UIs,Remote,100000,Design,http://example.com/2\n"
+
+	query := url.Values{}
+	query.Set("map[title]", "Job Title")
+
+	resp, err := Post(server.URL+"/jobs/import?"+query.Encode(), "text/csv", strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result["imported"] != float64(2) {
+		t.Errorf("Expected 2 imported jobs, got %v", result["imported"])
+	}
+
+	// Verify the jobs were saved with their duties split out
+	jobIDs := result["job_ids"].([]interface{})
+	if len(jobIDs) != 2 {
+		t.Fatalf("Expected 2 job IDs, got %d", len(jobIDs))
+	}
+
+	PublishJob(t, server.URL, jobIDs[0].(string))
+
+	var job map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobIDs[0].(string), &job)
+
+	if job["title"] != "Backend Developer" {
+		t.Errorf("Expected title 'Backend Developer', got '%v'", job["title"])
+	}
+
+	duties := job["duties"].([]interface{})
+	if len(duties) != 2 {
+		t.Errorf("Expected 2 duties, got %d", len(duties))
+	}
+}
+
+// TestImportJobs_AtomicRejectsInvalidRows tests that one bad row rejects the whole import
+func TestImportJobs_AtomicRejectsInvalidRows(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	ndjson := `{"title":"Backend Developer","description":"Build APIs","location":"Lagos","salary":120000,"duties":["Code"]}` + "\n" +
+		`{"description":"Missing title","location":"Remote","salary":90000,"duties":["Code"]}` + "\n"
+
+	resp, err := Post(server.URL+"/jobs/import", "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result["message"] != "import rejected, no jobs were saved" {
+		t.Errorf("Expected the import to be rejected, got %v", result["message"])
+	}
+
+	rowErrors := result["errors"].([]interface{})
+	if len(rowErrors) != 1 {
+		t.Fatalf("Expected 1 row error, got %d", len(rowErrors))
+	}
+
+	rowError := rowErrors[0].(map[string]interface{})
+	if rowError["line"] != float64(2) {
+		t.Errorf("Expected error on line 2, got %v", rowError["line"])
+	}
+
+	// Nothing should have been saved
+	getResp, err := http.Get(server.URL + "/jobs")
+	if err != nil {
+		t.Fatalf("Failed to fetch jobs: %v", err)
+	}
+	defer getResp.Body.Close()
+
+	var jobs map[string]interface{}
+	json.NewDecoder(getResp.Body).Decode(&jobs)
+
+	metadata := jobs["metadata"].(map[string]interface{})
+	if metadata["total"] != float64(0) {
+		t.Errorf("Expected total 0, got %v", metadata["total"])
+	}
+}
+
+// TestImportJobs_DryRun tests that a dry run validates without saving
+func TestImportJobs_DryRun(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	ndjson := `{"title":"Backend Developer","description":"Build APIs","location":"Lagos","salary":120000,"duties":["Code"]}` + "\n" +
+		`{"description":"Missing title","location":"Remote","salary":90000,"duties":["Code"]}` + "\n"
+
+	resp, err := Post(server.URL+"/jobs/import?dry_run=true&mode=partial", "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result["message"] != "dry run, no jobs were saved" {
+		t.Errorf("Expected a dry run, got %q", result["message"])
+	}
+
+	if result["valid"] != float64(1) || result["invalid"] != float64(1) {
+		t.Errorf("Expected 1 valid and 1 invalid row, got %v and %v", result["valid"], result["invalid"])
+	}
+
+	if result["imported"] != float64(0) {
+		t.Errorf("Expected 0 imported jobs, got %v", result["imported"])
+	}
+}
+
+// TestImportJobs_UnknownMode tests that an import mode other than atomic or partial is refused
+func TestImportJobs_UnknownMode(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	ndjson := `{"title":"Backend Developer","description":"Build APIs","location":"Lagos","salary":120000,"duties":["Code"]}` + "\n"
+
+	resp, err := Post(server.URL+"/jobs/import?mode=partal", "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	}
+
+	var count int
+	db.DB.QueryRow("SELECT COUNT(*) FROM jobs").Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected no jobs to be saved, got %d", count)
+	}
+}
+
+// TestImportJobs_Duplicates tests that rejected near-duplicates are
+// reported per row, and that rows of one file don't count as duplicates of
+// each other
+func TestImportJobs_Duplicates(t *testing.T) {
+	server := SetupTestAppWith(t, func(cfg *config.Config) {
+		cfg.Moderation.DuplicatePolicy = models.DuplicateReject
+	})
+	defer Teardown(t, server)
+
+	existing, _ := json.Marshal(duplicateJob("Backend Developer", backendDescription))
+	resp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(existing))
+	if err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	var created map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	existingID := created["job"].(map[string]interface{})["id"]
+
+	repeated, _ := json.Marshal(duplicateJob("Data Engineer", dataDescription))
+	ndjson := string(existing) + "\n" + string(repeated) + "\n" + string(repeated) + "\n"
+
+	// An atomic import is rejected as a whole
+	resp, err = Post(server.URL+"/jobs/import", "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409 for an atomic import, got %d", resp.StatusCode)
+	}
+
+	// A partial import saves the rest
+	resp, err = Post(server.URL+"/jobs/import?mode=partial", "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result["imported"] != float64(2) || result["invalid"] != float64(1) {
+		t.Errorf("Expected 2 imported rows and 1 rejected, got %v and %v", result["imported"], result["invalid"])
+	}
+
+	rowErrors := result["errors"].([]interface{})
+	if len(rowErrors) != 1 {
+		t.Fatalf("Expected 1 row error, got %v", rowErrors)
+	}
+	rowError := rowErrors[0].(map[string]interface{})
+	duplicates, _ := rowError["duplicates"].([]interface{})
+	if rowError["line"] != float64(1) || len(duplicates) != 1 || duplicates[0] != existingID {
+		t.Errorf("Expected line 1 to be rejected as a duplicate of %v, got %v", existingID, rowError)
+	}
+}
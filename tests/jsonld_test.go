@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// TestGetJob_JSONLD tests fetching a job as a schema.org JobPosting
+func TestGetJob_JSONLD(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	req, _ := http.NewRequest("GET", server.URL+"/jobs/"+jobID, nil)
+	req.Header.Set("Accept", "application/ld+json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/ld+json") {
+		t.Errorf("Unexpected Content-Type '%s'", resp.Header.Get("Content-Type"))
+	}
+
+	var posting map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&posting)
+
+	if posting["@type"] != "JobPosting" {
+		t.Errorf("Expected @type JobPosting, got %v", posting["@type"])
+	}
+
+	datePosted, err := time.Parse(time.RFC3339, posting["datePosted"].(string))
+	if err != nil {
+		t.Fatalf("Invalid datePosted: %v", err)
+	}
+
+	validThrough, err := time.Parse(time.RFC3339, posting["validThrough"].(string))
+	if err != nil {
+		t.Fatalf("Invalid validThrough: %v", err)
+	}
+
+	if !validThrough.Equal(datePosted.AddDate(0, 0, 14)) {
+		t.Errorf("Expected validThrough 14 days after datePosted, got %s", validThrough)
+	}
+
+	if posting["baseSalary"] == nil || posting["jobLocation"] == nil || posting["hiringOrganization"] == nil {
+		t.Error("JobPosting should include baseSalary, jobLocation and hiringOrganization")
+	}
+}
+
+// TestGetJob_JSONLDRoute tests the dedicated JSON-LD route
+func TestGetJob_JSONLDRoute(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	resp, err := http.Get(server.URL + "/jobs/" + jobID + "/jsonld")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var posting map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&posting)
+
+	identifier := posting["identifier"].(map[string]interface{})
+	if identifier["value"] != jobID {
+		t.Errorf("Expected identifier %s, got %v", jobID, identifier["value"])
+	}
+}
+
+// TestSitemap tests that the sitemap lists active jobs only
+func TestSitemap(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	// Insert a job that expired long ago
+	createdAt := time.Now().AddDate(0, 0, -30).Format(time.RFC3339)
+	_, err := db.DB.Exec(`INSERT INTO jobs(id, title, description, location, salary, duties, url, created_at)
+		VALUES('expired-job', 'Old Job', 'Old', 'Lagos', 1000, '[]', '', ?)`, createdAt)
+	if err != nil {
+		t.Fatalf("Failed to insert expired job: %v", err)
+	}
+
+	resp, err := http.Get(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var sitemap struct {
+		URLs []struct {
+			Loc string `xml:"loc"`
+		} `xml:"url"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&sitemap); err != nil {
+		t.Fatalf("Failed to parse sitemap: %v", err)
+	}
+
+	if len(sitemap.URLs) != 1 {
+		t.Fatalf("Expected 1 URL, got %d", len(sitemap.URLs))
+	}
+
+	if !strings.HasSuffix(sitemap.URLs[0].Loc, "/job/"+jobID) {
+		t.Errorf("Expected the job details page, got %s", sitemap.URLs[0].Loc)
+	}
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/logging"
+)
+
+// logBuffer collects log lines written from several goroutines
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// entries decodes every JSON log line written so far
+func (b *logBuffer) entries() []map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// captureLogs sends the default logger to a buffer until the test ends
+func captureLogs(t *testing.T) *logBuffer {
+	logs := &logBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(logging.New(logs, "debug", "json"))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return logs
+}
+
+// getWithRequestID sends a GET request with the given X-Request-ID, if any
+func getWithRequestID(t *testing.T, url, requestID string) *http.Response {
+	req, _ := http.NewRequest("GET", url, nil)
+	if requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// TestLogging_RequestID tests that request IDs are propagated or generated and tag the access log
+func TestLogging_RequestID(t *testing.T) {
+	logs := captureLogs(t)
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Logging Engineer")
+
+	resp := getWithRequestID(t, server.URL+"/jobs/"+jobID, "trace-abc-123")
+	if resp.Header.Get("X-Request-ID") != "trace-abc-123" {
+		t.Errorf("Expected the request ID to be echoed, got %q", resp.Header.Get("X-Request-ID"))
+	}
+
+	var access map[string]interface{}
+	for _, entry := range logs.entries() {
+		if entry["msg"] == "request" && entry["request_id"] == "trace-abc-123" {
+			access = entry
+		}
+	}
+	if access == nil {
+		t.Fatalf("Expected an access log line with the request ID")
+	}
+	if access["route"] != "/jobs/:id" || access["status"] != 200.0 || access["level"] != "INFO" {
+		t.Errorf("Expected an INFO line for /jobs/:id with status 200, got %v", access)
+	}
+
+	// Missing or unusable IDs are replaced with generated ones
+	for _, sent := range []string{"", strings.Repeat("x", 200), "has spaces"} {
+		resp := getWithRequestID(t, server.URL+"/jobs", sent)
+		if got := resp.Header.Get("X-Request-ID"); got == "" || got == sent {
+			t.Errorf("Expected a generated request ID for %q, got %q", sent, got)
+		}
+	}
+}
+
+// TestLogging_HandlerErrors tests that errors behind a 500 are logged with the request ID but not sent to the client
+func TestLogging_HandlerErrors(t *testing.T) {
+	logs := captureLogs(t)
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	// Every query fails once the database is closed
+	db.DB.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/jobs", nil)
+	req.Header.Set("X-Request-ID", "broken-db")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusInternalServerError || body["error"] != "could not fetch jobs" {
+		t.Fatalf("Expected a 500 with a generic message, got %d and %v", resp.StatusCode, body)
+	}
+
+	var handlerError, access map[string]interface{}
+	for _, entry := range logs.entries() {
+		if entry["request_id"] != "broken-db" {
+			continue
+		}
+		switch entry["msg"] {
+		case "could not fetch jobs":
+			handlerError = entry
+		case "request":
+			access = entry
+		}
+	}
+
+	if handlerError == nil || handlerError["level"] != "ERROR" || !strings.Contains(handlerError["error"].(string), "closed") {
+		t.Errorf("Expected the underlying error to be logged, got %v", handlerError)
+	}
+	if access == nil || access["level"] != "ERROR" || access["status"] != 500.0 {
+		t.Errorf("Expected the access line to be logged at ERROR, got %v", access)
+	}
+}
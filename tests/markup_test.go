@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Ademayowa/job-board/internal/markup"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the elements the sanitizer may let through
+var allowedTags = map[string]bool{
+	"p": true, "br": true, "hr": true, "strong": true, "em": true, "del": true,
+	"blockquote": true, "pre": true, "code": true, "ul": true, "ol": true, "li": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "a": true,
+}
+
+// allowedAttrs are the attributes the sanitizer may let through
+var allowedAttrs = map[string]bool{"href": true, "rel": true, "target": true, "start": true}
+
+// checkSanitized fails the test if the HTML contains anything outside the allowlist
+func checkSanitized(t *testing.T, source, rendered string) {
+	tokenizer := html.NewTokenizer(strings.NewReader(rendered))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				t.Fatalf("Could not parse output of %q: %v", source, tokenizer.Err())
+			}
+			return
+		}
+
+		switch tokenType {
+		case html.CommentToken, html.DoctypeToken:
+			t.Fatalf("Rendering %q let through %q", source, tokenizer.Token().String())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if !allowedTags[token.Data] {
+				t.Fatalf("Rendering %q let through the %s element: %s", source, token.Data, rendered)
+			}
+
+			for _, attr := range token.Attr {
+				if !allowedAttrs[attr.Key] {
+					t.Fatalf("Rendering %q let through the %s attribute: %s", source, attr.Key, rendered)
+				}
+				if attr.Key != "href" {
+					continue
+				}
+
+				link, err := url.Parse(attr.Val)
+				if err != nil || (link.Scheme != "http" && link.Scheme != "https" && link.Scheme != "mailto") {
+					t.Fatalf("Rendering %q let through the link %q", source, attr.Val)
+				}
+			}
+		}
+	}
+}
+
+// FuzzMarkupRender checks that no Markdown input produces HTML outside the allowlist
+func FuzzMarkupRender(f *testing.F) {
+	corpus := []string{
+		"**Build** scalable _APIs_",
+		"- Write code\n- Review PRs\n",
+		"1. First\n2. Second",
+		"# Heading\n\n> quote\n\n```\ncode\n```",
+		"[apply](https://example.com/apply)",
+		"[mail](mailto:jobs@example.com)",
+		"<script>alert(1)</script>",
+		"<img src=x onerror=alert(1)>",
+		"[click](javascript:alert(1))",
+		"[click](JaVaScRiPt:alert(1))",
+		"[click](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)",
+		"![image](https://example.com/x.png)",
+		"<a href=\"https://example.com\" onclick=\"alert(1)\">x</a>",
+		"<iframe src=\"https://example.com\"></iframe>",
+		"<!-- comment --><style>body{}</style>",
+		"<svg><script>alert(1)</script></svg>",
+		"[x](https://example.com \"title\" onmouseover=alert(1))",
+		"www.example.com and https://example.com/path?q=<script>",
+		"<<script>script>alert(1)<</script>/script>",
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+	}
+	for _, source := range corpus {
+		f.Add(source)
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		checkSanitized(t, source, markup.Render(source))
+		checkSanitized(t, source, markup.RenderInline(source))
+	})
+}
+
+// TestJobMarkdown tests that jobs expose their Markdown and sanitized HTML
+func TestJobMarkdown(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	job := map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build **scalable** APIs\n\n<script>alert(1)</script>",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write _clean_ code", "[Review](javascript:alert(1)) PRs"},
+	}
+
+	body, _ := json.Marshal(job)
+	resp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	var created map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	jobID := created["job"].(map[string]interface{})["id"].(string)
+	PublishJob(t, server.URL, jobID)
+
+	var result map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID, &result)
+
+	if result["description_markdown"] != job["description"] {
+		t.Errorf("Expected the Markdown source to be returned, got %v", result["description_markdown"])
+	}
+
+	descriptionHTML := result["description_html"].(string)
+	if !strings.Contains(descriptionHTML, "<strong>scalable</strong>") {
+		t.Errorf("Expected the description to be rendered, got %q", descriptionHTML)
+	}
+	if strings.Contains(descriptionHTML, "script") {
+		t.Errorf("Expected the script to be removed, got %q", descriptionHTML)
+	}
+
+	dutiesHTML := result["duties_html"].([]interface{})
+	if dutiesHTML[0] != "Write <em>clean</em> code" {
+		t.Errorf("Expected the first duty to be rendered inline, got %q", dutiesHTML[0])
+	}
+	if strings.Contains(dutiesHTML[1].(string), "javascript") {
+		t.Errorf("Expected the javascript link to be removed, got %q", dutiesHTML[1])
+	}
+
+	// Search engines get the sanitized HTML rather than the source
+	var posting map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID+"/jsonld", &posting)
+
+	description := posting["description"].(string)
+	if !strings.Contains(description, "<strong>scalable</strong>") || strings.Contains(description, "script") {
+		t.Errorf("Expected the JSON-LD description to be the sanitized HTML, got %q", description)
+	}
+	if posting["responsibilities"] != "Write clean code\nReview PRs" {
+		t.Errorf("Expected the responsibilities as plain text, got %q", posting["responsibilities"])
+	}
+
+	// and feed readers get plain text
+	resp, err = http.Get(server.URL + "/jobs/feed.rss")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var rss struct {
+		Descriptions []string `xml:"channel>item>description"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil || len(rss.Descriptions) != 1 {
+		t.Fatalf("Expected one item in the feed, got %v and %v", rss.Descriptions, err)
+	}
+	if summary := rss.Descriptions[0]; !strings.HasPrefix(summary, "Build scalable APIs\n") || strings.ContainsAny(summary, "*<_") {
+		t.Errorf("Expected the feed summary as plain text, got %q", summary)
+	}
+}
+
+// TestMarkupPlainText tests stripping rendered Markdown down to its text
+func TestMarkupPlainText(t *testing.T) {
+	tests := map[string]string{
+		"Build **scalable** APIs":            "Build scalable APIs",
+		"First\n\nSecond":                    "First\nSecond",
+		"- Write code\n- Review PRs\n":       "Write code\nReview PRs",
+		"Salary > 100k & equity":             "Salary > 100k & equity",
+		"[apply](https://example.com/apply)": "apply",
+		"Escape tags as &amp;lt;":            "Escape tags as &lt;",
+	}
+
+	for source, expected := range tests {
+		if text := markup.PlainText(markup.Render(source)); text != expected {
+			t.Errorf("Expected %q as %q, got %q", source, expected, text)
+		}
+	}
+}
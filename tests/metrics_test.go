@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrapeMetrics fetches the metrics in the Prometheus text format
+func scrapeMetrics(t *testing.T, serverURL string) string {
+	resp, err := http.Get(serverURL + "/metrics")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+// metricValue returns the value of the series written exactly as series, or 0 if it is missing
+func metricValue(metrics, series string) float64 {
+	for _, line := range strings.Split(metrics, "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			number, _ := strconv.ParseFloat(value, 64)
+			return number
+		}
+	}
+	return 0
+}
+
+// TestMetrics tests that traffic, database and business metrics are exposed
+func TestMetrics(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	// Counters are shared by every test, so only changes are compared
+	before := scrapeMetrics(t, server.URL)
+
+	jobID := createFeedJob(t, server.URL, "Metrics Engineer")
+
+	for _, path := range []string{"/jobs/" + jobID, "/jobs?query=astronaut", "/jobs?query=metrics", "/no-such-route"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	after := scrapeMetrics(t, server.URL)
+	changed := func(series string) float64 {
+		return metricValue(after, series) - metricValue(before, series)
+	}
+
+	// Requests are labelled by route pattern, never by the URL
+	if n := changed(`job_board_http_requests_total{method="GET",route="/jobs/:id",status="200"}`); n != 1 {
+		t.Errorf("Expected 1 request to /jobs/:id, got %v", n)
+	}
+	if n := changed(`job_board_http_requests_total{method="GET",route="unmatched",status="404"}`); n != 1 {
+		t.Errorf("Expected 1 unmatched request, got %v", n)
+	}
+	if strings.Contains(after, jobID) || strings.Contains(after, "no-such-route") {
+		t.Errorf("Expected no raw URLs in the metrics")
+	}
+	if n := changed(`job_board_http_request_duration_seconds_count{method="POST",route="/jobs"}`); n != 1 {
+		t.Errorf("Expected 1 timed request to POST /jobs, got %v", n)
+	}
+
+	// Business metrics
+	if n := changed(`job_board_jobs_created_total{source="api"}`); n != 1 {
+		t.Errorf("Expected 1 job created through the API, got %v", n)
+	}
+	if n := changed(`job_board_searches_total`); n != 2 {
+		t.Errorf("Expected 2 searches, got %v", n)
+	}
+	if n := changed(`job_board_searches_without_results_total`); n != 1 {
+		t.Errorf("Expected 1 search without results, got %v", n)
+	}
+	if n := metricValue(after, `job_board_jobs{state="active"}`); n != 1 {
+		t.Errorf("Expected 1 active job, got %v", n)
+	}
+
+	// Database pool statistics
+	for _, name := range []string{"job_board_db_open_connections", "job_board_db_in_use_connections", "job_board_db_wait_count_total"} {
+		if !strings.Contains(after, "\n"+name+" ") {
+			t.Errorf("Expected %s to be exposed", name)
+		}
+	}
+}
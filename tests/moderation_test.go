@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// TestReports_AutoHide tests that enough reports hide a job until a moderator approves it
+func TestReports_AutoHide(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createPublishedJob(t, server.URL, "alice", "Backend Developer")
+	jobURL := server.URL + "/jobs/" + jobID
+
+	// Unknown reasons are rejected
+	resp, _ := doWithRole(t, "dave", "employer", "POST", jobURL+"/reports", map[string]interface{}{"reason": "boring"})
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", resp.StatusCode)
+	}
+
+	report := func(reporter, reason string) int {
+		resp, _ := doWithRole(t, reporter, "employer", "POST", jobURL+"/reports", map[string]interface{}{"reason": reason, "details": "Asks for money"})
+		return resp.StatusCode
+	}
+
+	// Anonymous users can't report, even by claiming to be someone
+	for _, user := range []string{"", "dave"} {
+		body, _ := json.Marshal(map[string]interface{}{"reason": "fraud"})
+		req, _ := http.NewRequest("POST", jobURL+"/reports", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", user)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected an unauthenticated report as %q to get status 401, got %d", user, resp.StatusCode)
+		}
+	}
+
+	if status := report("dave", "fraud"); status != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", status)
+	}
+
+	// The same user can't report twice
+	if status := report("dave", "spam"); status != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", status)
+	}
+
+	report("erin", "fraud")
+	report("frank", "offensive")
+
+	// Hidden from the public and the lists, but not from the owner
+	resp, _ = doWithRole(t, "dave", "employer", "GET", jobURL, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a hidden job to be 404 for others, got %d", resp.StatusCode)
+	}
+
+	resp, owned := doWithRole(t, "alice", "employer", "GET", jobURL, nil)
+	if resp.StatusCode != http.StatusOK || owned["hidden"] != true {
+		t.Errorf("Expected the owner to see their hidden job, got %d %v", resp.StatusCode, owned)
+	}
+
+	_, list := doWithRole(t, "dave", "employer", "GET", server.URL+"/jobs", nil)
+	if list["metadata"].(map[string]interface{})["total"] != float64(0) {
+		t.Errorf("Expected the hidden job to be left out of the list, got %v", list["metadata"])
+	}
+
+	// The job waits in the queue with its reports
+	resp, _ = doWithRole(t, "alice", "employer", "GET", server.URL+"/moderation/queue", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for an employer, got %d", resp.StatusCode)
+	}
+
+	_, queue := doWithRole(t, "carol", "reviewer", "GET", server.URL+"/moderation/queue", nil)
+	items := queue["data"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 job in the queue, got %d", len(items))
+	}
+	if reports := items[0].(map[string]interface{})["reports"].([]interface{}); len(reports) != 3 {
+		t.Errorf("Expected 3 reports, got %d", len(reports))
+	}
+
+	resp, _ = doWithRole(t, "carol", "reviewer", "POST", server.URL+"/moderation/queue/"+jobID+"/approve", map[string]interface{}{"comment": "Legitimate"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doWithRole(t, "dave", "employer", "GET", jobURL, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the approved job to be public again, got %d", resp.StatusCode)
+	}
+
+	_, queue = doWithRole(t, "carol", "reviewer", "GET", server.URL+"/moderation/queue", nil)
+	if queue["total"] != float64(0) {
+		t.Errorf("Expected the queue to be empty, got %v", queue["total"])
+	}
+
+	// Both the automatic hide and the approval are on record
+	_, decisions := doWithRoleList(t, "carol", "reviewer", server.URL+"/moderation/decisions?job_id="+jobID)
+	if len(decisions) != 2 {
+		t.Fatalf("Expected 2 decisions on record, got %d", len(decisions))
+	}
+	if decisions[0].(map[string]interface{})["action"] != "approve" || decisions[1].(map[string]interface{})["action"] != "auto_hide" {
+		t.Errorf("Expected an automatic hide followed by an approval, got %v", decisions)
+	}
+}
+
+// TestModeration_RemoveAndBan tests removing a job and banning its employer
+func TestModeration_RemoveAndBan(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	first := createPublishedJob(t, server.URL, "mallory", "Backend Developer")
+	second := createPublishedJob(t, server.URL, "mallory", "Data Analyst")
+	third := createPublishedJob(t, server.URL, "mallory", "Product Designer")
+
+	resp, _ := doWithRole(t, "carol", "reviewer", "POST", server.URL+"/moderation/queue/"+first+"/remove", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doWithRole(t, "mallory", "employer", "GET", server.URL+"/jobs/"+first, nil)
+	if resp.StatusCode == http.StatusOK {
+		t.Errorf("Expected the removed job to be gone")
+	}
+
+	// Only admins may ban
+	resp, _ = doWithRole(t, "carol", "reviewer", "POST", server.URL+"/moderation/queue/"+second+"/ban-employer", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a reviewer, got %d", resp.StatusCode)
+	}
+
+	resp, result := doWithRole(t, "root", "admin", "POST", server.URL+"/moderation/queue/"+second+"/ban-employer", map[string]interface{}{"comment": "Scammer"})
+	if resp.StatusCode != http.StatusOK || result["owner_id"] != "mallory" {
+		t.Fatalf("Expected mallory to be banned, got %d %v", resp.StatusCode, result)
+	}
+
+	// The rest of the employer's jobs are hidden
+	resp, _ = doWithRole(t, "dave", "employer", "GET", server.URL+"/jobs/"+third, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the employer's other jobs to be hidden, got %d", resp.StatusCode)
+	}
+
+	// And the employer can't post again
+	resp, _ = doWithRole(t, "mallory", "employer", "POST", server.URL+"/jobs", map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a banned employer, got %d", resp.StatusCode)
+	}
+
+	_, decisions := doWithRoleList(t, "root", "admin", server.URL+"/moderation/decisions")
+	if len(decisions) != 2 {
+		t.Errorf("Expected 2 decisions on record, got %d", len(decisions))
+	}
+
+	// Removed jobs can't be brought back by their owner
+	for _, jobID := range []string{first, second} {
+		resp, _ = doWithRole(t, "mallory", "employer", "POST", server.URL+"/jobs/"+jobID+"/restore", nil)
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("Expected status 409 restoring a removed job, got %d", resp.StatusCode)
+		}
+	}
+
+	// Nor can a banned employer restore a job they deleted themselves
+	resp, _ = doWithRole(t, "mallory", "employer", "DELETE", server.URL+"/jobs/"+third, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the owner to delete their job, got %d", resp.StatusCode)
+	}
+	resp, _ = doWithRole(t, "mallory", "employer", "POST", server.URL+"/jobs/"+third+"/restore", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a banned employer restoring a job, got %d", resp.StatusCode)
+	}
+}
+
+// TestModeration_BanWithoutOwner tests that jobs nobody owns can't get
+// an employer banned
+func TestModeration_BanWithoutOwner(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createPublishedJob(t, server.URL, "alice", "Backend Developer")
+	if _, err := db.DB.Exec("UPDATE jobs SET owner_id = '' WHERE id = ?", jobID); err != nil {
+		t.Fatalf("Failed to clear the owner: %v", err)
+	}
+
+	resp, _ := doWithRole(t, "root", "admin", "POST", server.URL+"/moderation/queue/"+jobID+"/ban-employer", nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", resp.StatusCode)
+	}
+
+	// The job is still there to be removed
+	resp, _ = doWithRole(t, "root", "admin", "POST", server.URL+"/moderation/queue/"+jobID+"/remove", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
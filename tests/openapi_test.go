@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/openapi"
+	"github.com/Ademayowa/job-board/internal/response"
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIDocument is the part of the OpenAPI document the tests look at
+type openAPIDocument struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+		Responses  map[string]json.RawMessage `json:"responses"`
+		Parameters map[string]json.RawMessage `json:"parameters"`
+	} `json:"components"`
+}
+
+func loadOpenAPI(t *testing.T) openAPIDocument {
+	var document openAPIDocument
+	if err := json.Unmarshal(openapi.Spec, &document); err != nil {
+		t.Fatalf("Failed to parse the OpenAPI document: %v", err)
+	}
+	return document
+}
+
+// ginParam matches a parameter in a gin route
+var ginParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// openAPIPath turns a gin route such as /jobs/:id into /jobs/{id}
+func openAPIPath(route string) string {
+	return ginParam.ReplaceAllString(route, "{$1}")
+}
+
+// TestOpenAPIServed tests that the document and the docs page are served
+func TestOpenAPIServed(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	result := map[string]interface{}{}
+	status := getJSON(t, server.URL+"/openapi.json", &result)
+	if status != http.StatusOK || result["openapi"] != "3.1.0" {
+		t.Errorf("Expected an OpenAPI 3.1 document, got %d and %v", status, result["openapi"])
+	}
+
+	resp, err := http.Get(server.URL + "/docs")
+	if err != nil {
+		t.Fatalf("Failed to fetch docs: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected an HTML page, got %d and %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), `spec-url="/openapi.json"`) {
+		t.Errorf("Expected the docs page to render /openapi.json, got %s", body)
+	}
+	if strings.Contains(string(body), "/latest/") {
+		t.Errorf("Expected the docs page to load a pinned Redoc release, got %s", body)
+	}
+}
+
+// documentedPath returns the path documenting a route. Routes under
+// /api/v2 and the legacy unversioned routes are documented by their
+// /api/v1 counterpart.
+func documentedPath(document openAPIDocument, route string) string {
+	path := openAPIPath(route)
+	switch {
+	case strings.HasPrefix(path, "/api/v2/"):
+		return "/api/v1/" + strings.TrimPrefix(path, "/api/v2/")
+	case !strings.HasPrefix(path, "/api/") && document.Paths[path] == nil:
+		return "/api/v1" + path
+	}
+	return path
+}
+
+// TestOpenAPICoversRoutes tests that every registered route is documented, and nothing else
+func TestOpenAPICoversRoutes(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	document := loadOpenAPI(t)
+	router := server.Config.Handler.(*gin.Engine)
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		registered[strings.ToLower(route.Method)+" "+openAPIPath(route.Path)] = true
+	}
+
+	for _, route := range router.Routes() {
+		path, method := documentedPath(document, route.Path), strings.ToLower(route.Method)
+		if _, ok := document.Paths[path][method]; !ok {
+			t.Errorf("Route %s %s is missing from the OpenAPI document", route.Method, route.Path)
+		}
+		if !registered[method+" "+path] {
+			t.Errorf("Route %s %s has no counterpart %s", route.Method, route.Path, path)
+		}
+	}
+
+	for path, operations := range document.Paths {
+		for method := range operations {
+			if method != "parameters" && !registered[method+" "+path] {
+				t.Errorf("The OpenAPI document describes %s %s, which isn't a route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// TestOpenAPISchemas tests that the Job and Problem schemas match their types and every reference resolves
+func TestOpenAPISchemas(t *testing.T) {
+	document := loadOpenAPI(t)
+
+	for schema, value := range map[string]interface{}{"Job": models.Job{}, "Problem": response.Problem{}} {
+		fields := reflect.TypeOf(value)
+		for i := 0; i < fields.NumField(); i++ {
+			name := strings.Split(fields.Field(i).Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			if _, ok := document.Components.Schemas[schema].Properties[name]; !ok {
+				t.Errorf("Field %s of %s is missing from the %s schema", name, fields, schema)
+			}
+		}
+	}
+
+	refs := regexp.MustCompile(`"\$ref":\s*"#/components/(\w+)/(\w+)"`)
+	for _, match := range refs.FindAllStringSubmatch(string(openapi.Spec), -1) {
+		kind, name := match[1], match[2]
+
+		var found bool
+		switch kind {
+		case "schemas":
+			_, found = document.Components.Schemas[name]
+		case "responses":
+			_, found = document.Components.Responses[name]
+		case "parameters":
+			_, found = document.Components.Parameters[name]
+		}
+		if !found {
+			t.Errorf("Reference #/components/%s/%s doesn't resolve", kind, name)
+		}
+	}
+}
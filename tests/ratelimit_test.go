@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/ratelimit"
+)
+
+// fakeRedis runs the token bucket script's logic in Go, standing in for a Redis server
+type fakeRedis struct {
+	mu      sync.Mutex
+	buckets map[string][2]float64
+}
+
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	capacity := float64(args[0].(int))
+	rate, _ := strconv.ParseFloat(args[1].(string), 64)
+	now := float64(args[2].(int64))
+
+	state, ok := f.buckets[keys[0]]
+	if !ok {
+		state = [2]float64{capacity, now}
+	}
+	tokens, updated := state[0], state[1]
+
+	if now > updated {
+		tokens = math.Min(capacity, tokens+(now-updated)*rate)
+		updated = now
+	}
+
+	allowed, retry := int64(0), int64(0)
+	if tokens >= 1 {
+		tokens--
+		allowed = 1
+	} else {
+		retry = int64(math.Ceil((1 - tokens) / rate))
+	}
+
+	f.buckets[keys[0]] = [2]float64{tokens, updated}
+	return []interface{}{allowed, int64(math.Floor(tokens)), retry, int64(math.Ceil((capacity - tokens) / rate))}, nil
+}
+
+// TestRateLimit_Route tests that a limited route answers 429 once a client uses up its requests
+func TestRateLimit_Route(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	// Imports are limited to 5 a minute
+	importAs := func(user string) *http.Response {
+		req, _ := http.NewRequest("POST", server.URL+"/jobs/import?format=ndjson&dry_run=true", strings.NewReader(""))
+		Authenticate(req, user, "employer")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	for i := 5; i > 0; i-- {
+		resp := importAs("alice")
+		if resp.StatusCode == http.StatusTooManyRequests {
+			t.Fatalf("Expected request to be allowed with %d left", i)
+		}
+		if resp.Header.Get("RateLimit-Limit") != "5" || resp.Header.Get("RateLimit-Remaining") != strconv.Itoa(i-1) {
+			t.Errorf("Expected limit 5 with %d remaining, got %s and %s", i-1, resp.Header.Get("RateLimit-Limit"), resp.Header.Get("RateLimit-Remaining"))
+		}
+	}
+
+	resp := importAs("alice")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", resp.StatusCode)
+	}
+
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 12 {
+		t.Errorf("Expected a Retry-After of at most 12 seconds, got %q", resp.Header.Get("Retry-After"))
+	}
+	if resp.Header.Get("RateLimit-Policy") != "5;w=60" {
+		t.Errorf("Expected policy 5;w=60, got %q", resp.Header.Get("RateLimit-Policy"))
+	}
+
+	// Other clients have their own buckets
+	if resp := importAs("bob"); resp.StatusCode == http.StatusTooManyRequests {
+		t.Errorf("Expected another user not to be limited")
+	}
+
+	// Routes without a limit carry no headers
+	getResp, err := http.Get(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	getResp.Body.Close()
+	if getResp.Header.Get("RateLimit-Limit") != "" {
+		t.Errorf("Expected no rate limit headers on an unlimited route")
+	}
+}
+
+// TestRateLimit_UnverifiedClients tests that clients are only told apart
+// by what was verified, so made up users don't get buckets of their own
+func TestRateLimit_UnverifiedClients(t *testing.T) {
+	apiKey := "partner-key-0123456789abcdef0123456789"
+	server := SetupTestAppWith(t, func(cfg *config.Config) {
+		cfg.Auth.APIKeys = []string{apiKey}
+	})
+	defer Teardown(t, server)
+
+	importWith := func(header, value string) *http.Response {
+		req, _ := http.NewRequest("POST", server.URL+"/jobs/import?format=ndjson&dry_run=true", strings.NewReader(""))
+		req.Header.Set(header, value)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Users claimed without the gateway secret all count against the IP
+	for i := 0; i < 5; i++ {
+		importWith("X-User-ID", "user-"+strconv.Itoa(i))
+	}
+	if resp := importWith("X-User-ID", "user-5"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected made up users to share the IP's limit, got %d", resp.StatusCode)
+	}
+
+	// Unknown API keys are rejected outright
+	if resp := importWith("X-API-Key", "made-up-key"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected an unknown API key to get status 401, got %d", resp.StatusCode)
+	}
+
+	// A known key has its own bucket
+	if resp := importWith("X-API-Key", apiKey); resp.StatusCode == http.StatusTooManyRequests || resp.Header.Get("RateLimit-Remaining") != "4" {
+		t.Errorf("Expected the API key to have its own limit, got %d with %s remaining", resp.StatusCode, resp.Header.Get("RateLimit-Remaining"))
+	}
+}
+
+// TestRateLimit_Refill tests that buckets refill over time in both stores
+func TestRateLimit_Refill(t *testing.T) {
+	stores := map[string]ratelimit.Store{
+		"memory": ratelimit.NewMemoryStore(),
+		"redis":  ratelimit.NewRedisStore(&fakeRedis{buckets: map[string][2]float64{}}),
+	}
+
+	limit := ratelimit.Limit{Requests: 2, Per: 10 * time.Second}
+	start := time.Now()
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for i := 0; i < 2; i++ {
+				if result, _ := store.Take(ctx, "client", limit, start); !result.Allowed {
+					t.Fatalf("Expected request %d to be allowed", i+1)
+				}
+			}
+
+			result, err := store.Take(ctx, "client", limit, start)
+			if err != nil {
+				t.Fatalf("Take failed: %v", err)
+			}
+			if result.Allowed || result.RetryAfter != 5*time.Second {
+				t.Errorf("Expected to wait 5s for a token, got %+v", result)
+			}
+
+			// One token comes back every 5 seconds
+			result, _ = store.Take(ctx, "client", limit, start.Add(5*time.Second))
+			if !result.Allowed || result.Remaining != 0 {
+				t.Errorf("Expected a refilled token to be allowed, got %+v", result)
+			}
+		})
+	}
+}
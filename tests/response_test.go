@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestResponse_Envelope tests that successful v2 responses wrap their payload in data
+func TestResponse_Envelope(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	var recent map[string]interface{}
+	if status := getJSON(t, server.URL+"/api/v2/jobs/recent", &recent); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if jobs, ok := recent["data"].([]interface{}); !ok || len(jobs) != 1 {
+		t.Errorf("Expected the recent jobs under data, got %v", recent)
+	}
+	if _, ok := recent["pagination"]; ok {
+		t.Errorf("Expected no pagination for a plain list, got %v", recent["pagination"])
+	}
+
+	var job map[string]interface{}
+	getJSON(t, server.URL+"/api/v2/jobs/"+jobID, &job)
+	if data, _ := job["data"].(map[string]interface{}); data["id"] != jobID {
+		t.Errorf("Expected the job under data, got %v", job)
+	}
+
+	var page map[string]interface{}
+	getJSON(t, server.URL+"/api/v2/jobs?limit=1", &page)
+	pagination, _ := page["pagination"].(map[string]interface{})
+	if pagination["current_page"] != 1.0 || pagination["per_page"] != 1.0 || pagination["total"] != 1.0 || pagination["total_pages"] != 1.0 {
+		t.Errorf("Expected the page to be located in the list, got %v", page["pagination"])
+	}
+}
+
+// TestResponse_Problem tests that v2 errors are reported as problem details
+func TestResponse_Problem(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	resp, err := http.Get(server.URL + "/api/v2/nowhere")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown route, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Expected a problem content type, got %q", contentType)
+	}
+
+	var problem map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&problem)
+
+	if problem["type"] != "about:blank" || problem["title"] != "Not Found" || problem["status"] != 404.0 {
+		t.Errorf("Expected an about:blank problem titled Not Found, got %v", problem)
+	}
+	if problem["instance"] != "/api/v2/nowhere" || problem["detail"] != "no route matches GET /api/v2/nowhere" {
+		t.Errorf("Expected the request to be named, got %v", problem)
+	}
+}
+
+// TestResponse_ValidationProblem tests that invalid fields are listed in the v2 problem
+func TestResponse_ValidationProblem(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	body, _ := json.Marshal(map[string]interface{}{"description": "Build APIs", "location": "Lagos", "salary": 1000.0, "duties": []string{"Code"}})
+	resp, err := Post(server.URL+"/api/v2/jobs", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Expected a problem content type, got %q", contentType)
+	}
+
+	var problem map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&problem)
+
+	if problem["status"] != 422.0 || problem["detail"] != "job data is invalid" || problem["instance"] != "/api/v2/jobs" {
+		t.Errorf("Expected a 422 problem for the job, got %v", problem)
+	}
+	if _, ok := problem["error"]; ok {
+		t.Errorf("Expected no legacy error member, got %v", problem)
+	}
+
+	fieldErrors, _ := problem["errors"].([]interface{})
+	if len(fieldErrors) != 1 || fieldErrors[0].(map[string]interface{})["field"] != "title" {
+		t.Errorf("Expected the missing title to be listed, got %v", problem["errors"])
+	}
+}
+
+// TestResponse_V1 tests that v1 and the legacy routes keep the bodies they
+// had before v2 introduced the envelope
+func TestResponse_V1(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	for _, base := range []string{"/api/v1", ""} {
+		var job map[string]interface{}
+		getJSON(t, server.URL+base+"/jobs/"+jobID, &job)
+		if job["id"] != jobID {
+			t.Errorf("%s: expected the bare job, got %v", base, job)
+		}
+
+		var recent []map[string]interface{}
+		if status := getJSON(t, server.URL+base+"/jobs/recent", &recent); status != http.StatusOK || len(recent) != 1 {
+			t.Errorf("%s: expected a bare list of recent jobs, got %d %v", base, status, recent)
+		}
+
+		var page map[string]interface{}
+		getJSON(t, server.URL+base+"/jobs?limit=1", &page)
+		if metadata, _ := page["metadata"].(map[string]interface{}); metadata["total"] != 1.0 {
+			t.Errorf("%s: expected the page to be located under metadata, got %v", base, page)
+		}
+
+		body, _ := json.Marshal(map[string]interface{}{"description": "Build APIs", "location": "Lagos", "salary": 1000.0, "duties": []string{"Code"}})
+		resp, err := Post(server.URL+base+"/jobs", "application/json", bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var invalid map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&invalid)
+		resp.Body.Close()
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+			t.Errorf("%s: expected a plain JSON error, got %q", base, contentType)
+		}
+		if _, ok := invalid["errors"]; invalid["error"] != "job data is invalid" || !ok || invalid["status"] != nil {
+			t.Errorf("%s: expected an error with the invalid fields, got %v", base, invalid)
+		}
+	}
+
+	// Unknown routes outside v2 get gin's plain 404
+	resp, err := http.Get(server.URL + "/api/v1/nowhere")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("Expected a plain 404, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,256 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+)
+
+// updateJobTitle changes the title of a job, keeping its other fields,
+// and approves the edit so the job stays published
+func updateJobTitle(t *testing.T, serverURL, jobID, title string) {
+	job := map[string]interface{}{
+		"title":       title,
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+		"url":         "http://example.com/job/1",
+	}
+
+	resp := doAs(t, TestEmployer, "PUT", serverURL+"/jobs/"+jobID, job)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to update job, got status %d", resp.StatusCode)
+	}
+
+	approveJob(t, serverURL, jobID)
+}
+
+// approveJob publishes an edited job again
+func approveJob(t *testing.T, serverURL, jobID string) {
+	resp, _ := doWithRole(t, "root", "admin", "POST", serverURL+"/jobs/"+jobID+"/approve", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to approve the edit, got status %d", resp.StatusCode)
+	}
+}
+
+// getJSON fetches a URL and decodes its JSON body
+func getJSON(t *testing.T, url string, v interface{}) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	json.NewDecoder(resp.Body).Decode(v)
+	return resp.StatusCode
+}
+
+// getData fetches a URL and decodes the data of its response envelope
+func getData(t *testing.T, url string, v interface{}) int {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	status := getJSON(t, url, &envelope)
+	if len(envelope.Data) > 0 {
+		json.Unmarshal(envelope.Data, v)
+	}
+	return status
+}
+
+// TestJobRevisions tests that each edit keeps the previous version
+func TestJobRevisions(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+	updateJobTitle(t, server.URL, jobID, "Senior Backend Developer")
+	updateJobTitle(t, server.URL, jobID, "Lead Backend Developer")
+
+	var revisions []map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID+"/revisions", &revisions)
+
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+
+	var revision map[string]interface{}
+	status := getJSON(t, server.URL+"/jobs/"+jobID+"/revisions/1", &revision)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+
+	job := revision["job"].(map[string]interface{})
+	if job["title"] != "Backend Developer" {
+		t.Errorf("Expected revision 1 to be titled 'Backend Developer', got '%v'", job["title"])
+	}
+
+	// Compare the first version with the job as it is now
+	var diff map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID+"/revisions/1/diff", &diff)
+
+	changes := diff["changes"].(map[string]interface{})
+	title := changes["title"].(map[string]interface{})
+	if len(changes) != 1 || title["before"] != "Backend Developer" || title["after"] != "Lead Backend Developer" {
+		t.Errorf("Unexpected diff %v", changes)
+	}
+
+	if status := getJSON(t, server.URL+"/jobs/"+jobID+"/revisions/9", &revision); status != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing revision, got %d", status)
+	}
+}
+
+// TestRevertJob tests rolling a job back to an earlier revision
+func TestRevertJob(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+	updateJobTitle(t, server.URL, jobID, "Wrong Title")
+
+	// Only the owner and admins may revert it
+	resp := doAs(t, "mallory", "POST", server.URL+"/jobs/"+jobID+"/revisions/1/revert", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected another employer to get status 403, got %d", resp.StatusCode)
+	}
+
+	resp = doAs(t, TestEmployer, "POST", server.URL+"/jobs/"+jobID+"/revisions/1/revert", nil)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	approveJob(t, server.URL, jobID)
+
+	var job map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID, &job)
+	if job["title"] != "Backend Developer" {
+		t.Errorf("Expected title 'Backend Developer' after revert, got '%v'", job["title"])
+	}
+
+	// The reverted version is kept as a revision too
+	var revisions []map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID+"/revisions", &revisions)
+	if len(revisions) != 2 {
+		t.Errorf("Expected 2 revisions after revert, got %d", len(revisions))
+	}
+}
+
+// TestGetJobAt tests viewing a job as it looked at an earlier time
+func TestGetJobAt(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+	updateJobTitle(t, server.URL, jobID, "Senior Backend Developer")
+	updateJobTitle(t, server.URL, jobID, "Lead Backend Developer")
+
+	// Move the history back in time: created ten days ago,
+	// first edited five days ago and edited again today
+	now := time.Now()
+	db.DB.Exec("UPDATE jobs SET created_at = ? WHERE id = ?", now.AddDate(0, 0, -10).Format(time.RFC3339), jobID)
+	db.DB.Exec("UPDATE job_revisions SET created_at = ? WHERE job_id = ? AND revision = 1", now.AddDate(0, 0, -5).UTC().Format(time.RFC3339), jobID)
+
+	tests := []struct {
+		daysAgo int
+		title   string
+	}{
+		{7, "Backend Developer"},
+		{3, "Senior Backend Developer"},
+		{-1, "Lead Backend Developer"},
+	}
+
+	for _, tt := range tests {
+		at := url.QueryEscape(now.AddDate(0, 0, -tt.daysAgo).Format(time.RFC3339))
+
+		var job map[string]interface{}
+		getJSON(t, server.URL+"/jobs/"+jobID+"?at="+at, &job)
+
+		if job["title"] != tt.title {
+			t.Errorf("%d days ago: expected title '%s', got '%v'", tt.daysAgo, tt.title, job["title"])
+		}
+	}
+}
+
+// TestGetJobAt_FollowsCurrentJob tests that earlier versions of a job are
+// hidden once the job itself is
+func TestGetJobAt_FollowsCurrentJob(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createPublishedJob(t, server.URL, "alice", "Backend Developer")
+	db.DB.Exec("UPDATE jobs SET created_at = ? WHERE id = ?", time.Now().AddDate(0, 0, -2).Format(time.RFC3339), jobID)
+	at := url.QueryEscape(time.Now().AddDate(0, 0, -1).Format(time.RFC3339))
+
+	// The edit sends the job back to review
+	resp, _ := doWithRole(t, "alice", "employer", "PUT", server.URL+"/jobs/"+jobID, map[string]interface{}{
+		"title":       "Senior Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to update job, got status %d", resp.StatusCode)
+	}
+
+	var job map[string]interface{}
+	if status := getJSON(t, server.URL+"/jobs/"+jobID+"?at="+at, &job); status != http.StatusNotFound {
+		t.Errorf("Expected the published version to be hidden while the edit is reviewed, got %d %v", status, job)
+	}
+
+	resp, job = doWithRole(t, "alice", "employer", "GET", server.URL+"/jobs/"+jobID+"?at="+at, nil)
+	if resp.StatusCode != http.StatusOK || job["title"] != "Backend Developer" {
+		t.Errorf("Expected the owner to see the published version, got %d %v", resp.StatusCode, job["title"])
+	}
+}
+
+// TestRevisions_Private tests that the versions of a job are only shown
+// to those who can see the job
+func TestRevisions_Private(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+	resp, _ := doWithRole(t, "alice", "employer", "PUT", server.URL+"/jobs/"+jobID, map[string]interface{}{
+		"title":       "Senior Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to update job, got status %d", resp.StatusCode)
+	}
+
+	at := url.QueryEscape(time.Now().Format(time.RFC3339))
+	for _, path := range []string{"/revisions", "/revisions/1", "/revisions/1/diff", "?at=" + at} {
+		var data interface{}
+		if status := getJSON(t, server.URL+"/jobs/"+jobID+path, &data); status != http.StatusNotFound {
+			t.Errorf("%s: expected an anonymous request to get status 404, got %d", path, status)
+		}
+
+		resp, _ := doWithRole(t, "bob", "employer", "GET", server.URL+"/jobs/"+jobID+path, nil)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected another employer to get status 404, got %d", path, resp.StatusCode)
+		}
+
+		resp, _ = doWithRole(t, "alice", "employer", "GET", server.URL+"/jobs/"+jobID+path, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected the owner to get status 200, got %d", path, resp.StatusCode)
+		}
+	}
+
+	// A job that doesn't exist at all is not found either
+	var data interface{}
+	if status := getJSON(t, server.URL+"/jobs/missing?at="+at, &data); status != http.StatusNotFound {
+		t.Errorf("Expected a missing job to get status 404, got %d", status)
+	}
+}
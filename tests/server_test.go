@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/server"
+)
+
+// startServer serves handler on a free local port until the returned cancel function is called
+func startServer(t *testing.T, handler http.Handler, cfg config.Server) (string, context.CancelFunc, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ctx, listener, handler, cfg)
+	}()
+
+	return listener.Addr().String(), cancel, served
+}
+
+// TestServer_GracefulShutdown tests that in-flight requests finish when the server is stopped
+func TestServer_GracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	addr, stop, served := startServer(t, handler, config.Default().Server)
+
+	type response struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/")
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		responses <- response{status: resp.StatusCode, body: string(body)}
+	}()
+
+	<-started
+	stop()
+
+	result := <-responses
+	if result.err != nil || result.status != http.StatusOK || result.body != "done" {
+		t.Errorf("Expected the in-flight request to finish, got %+v", result)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+
+	if _, err := http.Get("http://" + addr + "/"); err == nil {
+		t.Errorf("Expected new connections to be refused after shutdown")
+	}
+}
+
+// TestServer_ShutdownDeadline tests that requests running past the shutdown timeout are cut off
+func TestServer_ShutdownDeadline(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	})
+
+	cfg := config.Default().Server
+	cfg.ShutdownTimeout = config.Duration(100 * time.Millisecond)
+	addr, stop, served := startServer(t, handler, cfg)
+
+	go http.Get("http://" + addr + "/")
+	<-started
+
+	begin := time.Now()
+	stop()
+
+	if err := <-served; err != context.DeadlineExceeded {
+		t.Errorf("Expected the shutdown deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("Expected shutdown to give up after the timeout, took %s", elapsed)
+	}
+}
+
+// writeCertificate writes a self-signed certificate with the given serial number and its key
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	os.Chtimes(certFile, modTime, modTime)
+	os.Chtimes(keyFile, modTime, modTime)
+}
+
+// TestServer_TLSReload tests that a renewed certificate is served without a restart
+func TestServer_TLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, 1, time.Now().Add(-time.Minute))
+
+	cfg := config.Default().Server
+	cfg.TLS = config.TLS{CertFile: certFile, KeyFile: keyFile, ReloadInterval: config.Duration(20 * time.Millisecond)}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})
+	addr, stop, served := startServer(t, handler, cfg)
+	defer func() {
+		stop()
+		<-served
+	}()
+
+	servedSerial := func() int64 {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("TLS handshake failed: %v", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	if serial := servedSerial(); serial != 1 {
+		t.Fatalf("Expected certificate 1, got %d", serial)
+	}
+
+	writeCertificate(t, certFile, keyFile, 2, time.Now())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for servedSerial() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the renewed certificate to be served")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// TestWorkers_StopOrder tests that workers stop one at a time, last started first
+func TestWorkers_StopOrder(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		stopped []string
+	)
+
+	var workers server.Workers
+	for _, name := range []string{"first", "second", "third"} {
+		workers.Start(name, func(ctx context.Context) {
+			<-ctx.Done()
+			mu.Lock()
+			stopped = append(stopped, name)
+			mu.Unlock()
+		})
+	}
+
+	// A worker that ignores cancellation doesn't hold up the rest
+	workers.Start("stuck", func(ctx context.Context) {
+		time.Sleep(time.Second)
+	})
+
+	workers.Stop(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"third", "second", "first"}
+	if len(stopped) != len(expected) {
+		t.Fatalf("Expected %v to stop, got %v", expected, stopped)
+	}
+	for i := range expected {
+		if stopped[i] != expected[i] {
+			t.Errorf("Expected stop order %v, got %v", expected, stopped)
+			break
+		}
+	}
+}
@@ -1,48 +1,65 @@
 package tests
 
 import (
-	"database/sql"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
+	"github.com/Ademayowa/job-board/internal/config"
 	db "github.com/Ademayowa/job-board/internal/database"
 	routes "github.com/Ademayowa/job-board/internal/handlers"
 	"github.com/gin-gonic/gin"
 )
 
+// TestGatewaySecret is the secret requests made through the test gateway carry
+const TestGatewaySecret = "test-gateway-secret-0123456789abcdef"
+
 // SetupTestApp sets up the test environment
 func SetupTestApp(t *testing.T) *httptest.Server {
+	return SetupTestAppWith(t, nil)
+}
+
+// SetupTestAppWith sets up the test environment with the configuration
+// changed by configure
+func SetupTestAppWith(t *testing.T, configure func(cfg *config.Config)) *httptest.Server {
+	return SetupTestAppServing(t, configure, routes.Dependencies{})
+}
+
+// SetupTestAppServing sets up the test environment with the configuration
+// changed by configure, serving requests with deps
+func SetupTestAppServing(t *testing.T, configure func(cfg *config.Config), deps routes.Dependencies) *httptest.Server {
 	gin.SetMode(gin.TestMode)
 
-	// Setup in-memory test database
+	// Setup a throwaway test database. A file is used rather than
+	// ":memory:" so every pooled connection sees the same data.
 	var err error
-	db.DB, err = sql.Open("sqlite", ":memory:")
+	db.DB, err = db.Open(filepath.Join(t.TempDir(), "test.db"))
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
 
-	// Create jobs table
-	createTable := `
-	CREATE TABLE IF NOT EXISTS jobs (
-		id TEXT PRIMARY KEY,
-		title TEXT NOT NULL,
-		description TEXT NOT NULL,
-		location TEXT NOT NULL,
-		salary FLOAT NOT NULL,
-		duties TEXT NOT NULL,
-		url TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`
-
-	if _, err = db.DB.Exec(createTable); err != nil {
-		t.Fatalf("Failed to create table: %v", err)
+	// Create tables
+	if err = db.Migrate(); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
 	}
 
 	// Setup router
 	router := gin.New()
-	routes.RegisterRoutes(router)
+	cfg := config.Default()
+	cfg.Auth.GatewaySecret = TestGatewaySecret
+	if configure != nil {
+		configure(&cfg)
+	}
+	routes.RegisterRoutes(router, cfg, deps)
 
-	return httptest.NewServer(router)
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = cfg.Server.WriteTimeout.Duration()
+	server.Start()
+	return server
 }
 
 func Teardown(t *testing.T, server *httptest.Server) {
@@ -51,3 +68,125 @@ func Teardown(t *testing.T, server *httptest.Server) {
 		db.DB.Close()
 	}
 }
+
+// PublishJob takes a draft job through review so it is publicly listed
+func PublishJob(t *testing.T, serverURL, jobID string) {
+	for _, action := range []string{"submit", "approve"} {
+		req, _ := http.NewRequest("POST", serverURL+"/jobs/"+jobID+"/"+action, nil)
+		Authenticate(req, "root", "admin")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to %s job: %v", action, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Failed to %s job, got status %d", action, resp.StatusCode)
+		}
+	}
+}
+
+// Authenticate sends req through the test gateway as user acting in role
+func Authenticate(req *http.Request, user, role string) {
+	req.Header.Set("X-Gateway-Secret", TestGatewaySecret)
+	req.Header.Set("X-User-ID", user)
+	req.Header.Set("X-User-Role", role)
+}
+
+// TestEmployer is the employer requests are made as when a test doesn't
+// say who makes them
+const TestEmployer = "test-employer"
+
+// Post is http.Post made through the test gateway as TestEmployer
+func Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	Authenticate(req, TestEmployer, "employer")
+
+	return http.DefaultClient.Do(req)
+}
+
+// doAs sends a request with a JSON body as user acting as an employer.
+// The caller closes the response body.
+func doAs(t *testing.T, user, method, url string, body interface{}) *http.Response {
+	return doAsRole(t, user, "employer", method, url, body)
+}
+
+// doAsRole sends a request with a JSON body as user acting in role. The
+// caller closes the response body.
+func doAsRole(t *testing.T, user, role, method, url string, body interface{}) *http.Response {
+	var encoded []byte
+	if body != nil {
+		encoded, _ = json.Marshal(body)
+	}
+
+	req, _ := http.NewRequest(method, url, bytes.NewReader(encoded))
+	req.Header.Set("Content-Type", "application/json")
+	Authenticate(req, user, role)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+// doWithRole sends a request on behalf of a user acting in the given role
+// and decodes the JSON object it answers with
+func doWithRole(t *testing.T, user, role, method, url string, body interface{}) (*http.Response, map[string]interface{}) {
+	resp := doAsRole(t, user, role, method, url, body)
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp, result
+}
+
+// doWithRoleList fetches a JSON list on behalf of a user acting in a role
+func doWithRoleList(t *testing.T, user, role, url string) (*http.Response, []interface{}) {
+	resp := doAsRole(t, user, role, "GET", url, nil)
+	defer resp.Body.Close()
+
+	var result []interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp, result
+}
+
+// createDraftJob creates a job owned by the given employer and returns its ID
+func createDraftJob(t *testing.T, serverURL, owner string) string {
+	job := map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	}
+
+	_, result := doWithRole(t, owner, "employer", "POST", serverURL+"/jobs", job)
+	created := result["job"].(map[string]interface{})
+
+	if created["status"] != "draft" {
+		t.Fatalf("Expected a new job to be a draft, got %v", created["status"])
+	}
+	return created["id"].(string)
+}
+
+// createPublishedJob creates and publishes a job owned by the given employer
+func createPublishedJob(t *testing.T, serverURL, owner, title string) string {
+	_, result := doWithRole(t, owner, "employer", "POST", serverURL+"/jobs", map[string]interface{}{
+		"title":       title,
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	})
+	jobID := result["job"].(map[string]interface{})["id"].(string)
+
+	doWithRole(t, owner, "employer", "POST", serverURL+"/jobs/"+jobID+"/submit", nil)
+	doWithRole(t, "carol", "reviewer", "POST", serverURL+"/jobs/"+jobID+"/approve", nil)
+	return jobID
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/handlers"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/spam"
+)
+
+// fixedScorer gives every job the same score, standing in for a model
+type fixedScorer float64
+
+func (s fixedScorer) Score(job models.Job) spam.Result {
+	return spam.Result{Score: float64(s), Reasons: []string{"model says so"}}
+}
+
+// TestSpam_HoldsSuspiciousJobs tests that spammy jobs are held until released
+func TestSpam_HoldsSuspiciousJobs(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	job := map[string]interface{}{
+		"title":       "EARN MONEY FAST FROM HOME TODAY",
+		"description": "Pay the registration fee by wire transfer to start.",
+		"location":    "Remote",
+		"salary":      120000.0,
+		"duties":      []string{"Send money"},
+		"url":         "https://bit.ly/abc",
+	}
+
+	resp, result := doWithRole(t, "mallory", "employer", "POST", server.URL+"/jobs", job)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	held := result["job"].(map[string]interface{})
+	if held["status"] != "held" {
+		t.Fatalf("Expected the job to be held, got %v", held["status"])
+	}
+	if _, ok := held["spam_score"]; ok {
+		t.Errorf("Expected the spam score to be kept out of the API")
+	}
+	jobURL := server.URL + "/jobs/" + held["id"].(string)
+
+	// A held job can't be sent for review
+	resp, _ = doWithRole(t, "mallory", "employer", "POST", jobURL+"/submit", nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", resp.StatusCode)
+	}
+
+	// Only staff can release it
+	resp, _ = doWithRole(t, "mallory", "employer", "POST", jobURL+"/release", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", resp.StatusCode)
+	}
+
+	resp, result = doWithRole(t, "carol", "reviewer", "POST", jobURL+"/release", nil)
+	if resp.StatusCode != http.StatusOK || result["job"].(map[string]interface{})["status"] != "draft" {
+		t.Errorf("Expected the job to be released as a draft, got %d %v", resp.StatusCode, result)
+	}
+
+	// An ordinary job is not held
+	_, result = doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+		"url":         "https://example.com/jobs/1",
+	})
+	if result["job"].(map[string]interface{})["status"] != "draft" {
+		t.Errorf("Expected an ordinary job to be a draft, got %v", result["job"])
+	}
+}
+
+// TestSpam_RulesReload tests that changes to the rules file are picked up
+func TestSpam_RulesReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spam_rules.json")
+	if err := os.WriteFile(path, []byte(`{"hold_threshold": 5, "keywords": {"weight": 5, "blocked": ["crypto"]}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+
+	rules, err := spam.LoadRules(path)
+	if err != nil {
+		t.Fatalf("Failed to load rules: %v", err)
+	}
+	pipeline := spam.NewPipeline(rules)
+
+	job := models.Job{Title: "Crypto trader", Description: "Trade crypto", Location: "Remote", Salary: 90000, Duties: []string{"Trade"}}
+	if !pipeline.Check(job).Hold {
+		t.Errorf("Expected the blocked keyword to hold the job")
+	}
+
+	// Rules missing from the file keep their defaults
+	if rules.Config().Salary.Max != spam.DefaultConfig().Salary.Max {
+		t.Errorf("Expected the default salary rule, got %v", rules.Config().Salary)
+	}
+
+	// Unblock the keyword
+	if err := os.WriteFile(path, []byte(`{"hold_threshold": 5, "keywords": {"weight": 5, "blocked": []}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+
+	if err := rules.Reload(); err != nil {
+		t.Fatalf("Failed to reload rules: %v", err)
+	}
+	if pipeline.Check(job).Hold {
+		t.Errorf("Expected the reloaded rules to let the job through")
+	}
+
+	// A broken file leaves the rules in force
+	os.WriteFile(path, []byte(`{"hold_threshold":`), 0o644)
+	later = later.Add(time.Second)
+	os.Chtimes(path, later, later)
+
+	if err := rules.Reload(); err == nil {
+		t.Errorf("Expected an error for an invalid rules file")
+	}
+	if rules.Config().HoldThreshold != 5 {
+		t.Errorf("Expected the previous rules to stay in force, got %v", rules.Config())
+	}
+}
+
+// TestSpam_HoldsSuspiciousEdits tests that an edit that looks like spam
+// takes a published job down until a moderator releases it
+func TestSpam_HoldsSuspiciousEdits(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "mallory")
+	PublishJob(t, server.URL, jobID)
+	jobURL := server.URL + "/jobs/" + jobID
+
+	resp, _ := doWithRole(t, "mallory", "employer", "PUT", jobURL, map[string]interface{}{
+		"title":       "EARN MONEY FAST FROM HOME TODAY",
+		"description": "Pay the registration fee by wire transfer to start.",
+		"location":    "Remote",
+		"salary":      120000.0,
+		"duties":      []string{"Send money"},
+		"url":         "https://bit.ly/abc",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	_, result := doWithRole(t, "mallory", "employer", "GET", jobURL, nil)
+	if status := result["status"]; status != "held" {
+		t.Fatalf("Expected the edited job to be held, got %v", status)
+	}
+
+	var job map[string]interface{}
+	if status := getJSON(t, jobURL, &job); status != http.StatusNotFound {
+		t.Errorf("Expected the held job to be taken down, got %d", status)
+	}
+
+	_, result = doWithRole(t, "carol", "reviewer", "GET", server.URL+"/moderation/queue", nil)
+	if queue := result["data"].([]interface{}); len(queue) != 1 {
+		t.Errorf("Expected the held job in the moderation queue, got %v", queue)
+	}
+}
+
+// TestSpam_ExtraScorer tests that scorers besides the rules count towards holding a job
+func TestSpam_ExtraScorer(t *testing.T) {
+	server := SetupTestAppServing(t, nil, handlers.Dependencies{
+		SpamFilter: spam.NewPipeline(spam.NewRules(spam.DefaultConfig()), fixedScorer(100)),
+	})
+	defer Teardown(t, server)
+
+	_, result := doWithRole(t, "alice", "employer", "POST", server.URL+"/jobs", map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      []string{"Write code"},
+	})
+
+	if result["job"].(map[string]interface{})["status"] != "held" {
+		t.Errorf("Expected the model's score to hold the job, got %v", result["job"])
+	}
+}
@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/models"
+
+	"modernc.org/sqlite"
+)
+
+// setQueryTimeout changes the query timeout until the test ends
+func setQueryTimeout(t *testing.T, timeout time.Duration) {
+	db.SetQueryTimeout(timeout)
+	t.Cleanup(func() { db.SetQueryTimeout(5 * time.Second) })
+}
+
+// TestQueryCancelled tests that data-access calls give up once their context is cancelled
+func TestQueryCancelled(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := models.GetAllJobs(ctx, "", 1, 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected reading jobs to be cancelled, got %v", err)
+	}
+
+	job := models.Job{Title: "Go Developer", Description: "Build APIs", Location: "Lagos", Salary: 120000, Duties: []string{"Code"}}
+	if err := job.Save(ctx, "user-1", models.DuplicateWarn); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected saving a job to be cancelled, got %v", err)
+	}
+
+	var count int
+	if err := db.DB.QueryRow("SELECT COUNT(*) FROM jobs").Scan(&count); err != nil || count != 0 {
+		t.Errorf("Expected the cancelled save to write nothing, got %d jobs and %v", count, err)
+	}
+}
+
+// TestSlowQueryInterrupted tests that a running query stops soon after its context ends
+func TestSlowQueryInterrupted(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+	setQueryTimeout(t, 100*time.Millisecond)
+
+	ctx, cancel := db.WithTimeout(context.Background())
+	defer cancel()
+
+	// Counts forever unless interrupted
+	endless := "WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT MAX(x) FROM n"
+
+	start := time.Now()
+	var max int
+	err := db.DB.QueryRowContext(ctx, endless).Scan(&max)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatalf("Expected the query to be interrupted")
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Expected the query to stop promptly after the timeout, took %s", elapsed)
+	}
+
+	// The connection is still usable afterwards
+	if err := db.DB.PingContext(context.Background()); err != nil {
+		t.Errorf("Expected the database to still respond, got %v", err)
+	}
+}
+
+// TestQueryTimeoutResponse tests that a request whose query runs out of time gets a 503
+func TestQueryTimeoutResponse(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+	setQueryTimeout(t, time.Nanosecond)
+
+	result := map[string]interface{}{}
+	status := getJSON(t, server.URL+"/jobs", &result)
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d and %v", status, result)
+	}
+}
+
+// endlessJobs puts a view that repeats the stored jobs forever in place of
+// the jobs table, so every read of it runs until it is interrupted
+func endlessJobs(t *testing.T) {
+	for _, statement := range []string{
+		"ALTER TABLE jobs RENAME TO stored_jobs",
+		"CREATE VIEW jobs AS SELECT stored_jobs.* FROM stored_jobs, (WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT x FROM n)",
+	} {
+		if _, err := db.DB.Exec(statement); err != nil {
+			t.Fatalf("Failed to replace the jobs table: %v", err)
+		}
+	}
+}
+
+// test_sleep(ms) pauses the query that calls it, for views standing in for
+// slow tables
+func init() {
+	sqlite.MustRegisterScalarFunction("test_sleep", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		time.Sleep(time.Duration(args[0].(int64)) * time.Millisecond)
+		return int64(0), nil
+	})
+}
+
+// slowJobs puts a view that takes a while to return each stored job in
+// place of the jobs table
+func slowJobs(t *testing.T) {
+	for _, statement := range []string{
+		"ALTER TABLE jobs RENAME TO stored_jobs",
+		"CREATE VIEW jobs AS SELECT * FROM stored_jobs WHERE test_sleep(200) = 0",
+	} {
+		if _, err := db.DB.Exec(statement); err != nil {
+			t.Fatalf("Failed to replace the jobs table: %v", err)
+		}
+	}
+}
+
+// waitForIdleConnections fails the test unless every database connection
+// is given back within timeout, which a query left running would hold
+func waitForIdleConnections(t *testing.T, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for db.DB.Stats().InUse > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the query to be interrupted, %d connections still in use", db.DB.Stats().InUse)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSlowQueryCancelledByClient tests that a client going away during a
+// slow query stops the query
+func TestSlowQueryCancelledByClient(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createFeedJob(t, server.URL, "Backend Developer")
+	endlessJobs(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/jobs", nil)
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected the request to be aborted, got status %d", resp.StatusCode)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the request to be cancelled, got %v", err)
+	}
+
+	waitForIdleConnections(t, 2*time.Second)
+}
+
+// TestSlowQueryTimesOut tests that a request whose query is still running
+// at the query timeout gets a 503
+func TestSlowQueryTimesOut(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createFeedJob(t, server.URL, "Backend Developer")
+	endlessJobs(t)
+	setQueryTimeout(t, 200*time.Millisecond)
+
+	start := time.Now()
+	result := map[string]interface{}{}
+	if status := getJSON(t, server.URL+"/api/v1/jobs", &result); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d and %v", status, result)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the query to stop at the timeout, took %s", elapsed)
+	}
+
+	waitForIdleConnections(t, time.Second)
+}
+
+// TestExportTimesOut tests that an export stops at the export timeout
+func TestExportTimesOut(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createFeedJob(t, server.URL, "Backend Developer")
+	endlessJobs(t)
+	db.SetExportTimeout(200 * time.Millisecond)
+	t.Cleanup(func() { db.SetExportTimeout(5 * time.Minute) })
+
+	// The export streams the same job until it is stopped, then the
+	// download fails rather than ending as if it were complete
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(server.URL + "/api/v1/jobs/export?format=ndjson")
+	if err == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil || strings.Contains(err.Error(), "Client.Timeout") {
+		t.Fatalf("Expected the export to fail at the timeout, got %v", err)
+	}
+
+	waitForIdleConnections(t, time.Second)
+}
+
+// TestExportOutlivesWriteTimeout tests that downloads of every job may take
+// longer than other responses
+func TestExportOutlivesWriteTimeout(t *testing.T) {
+	server := SetupTestAppWith(t, func(cfg *config.Config) {
+		cfg.Server.WriteTimeout = config.Duration(50 * time.Millisecond)
+	})
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+	slowJobs(t)
+
+	for _, path := range []string{"/jobs/export?format=csv", "/sitemap.xml"} {
+		started := time.Now()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Errorf("%s: expected the download to outlive the write timeout, got %v", path, err)
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(string(body), jobID) {
+			t.Errorf("%s: expected the full download, got %d %q and %v", path, resp.StatusCode, body, err)
+		}
+		if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+			t.Errorf("%s: expected the download to take longer than the write timeout, took %s", path, elapsed)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider that keeps every span in memory
+// until the test ends. It must be called before SetupTestApp.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	if _, err := tracing.Setup(context.Background(), config.Tracing{Exporter: "none"}, nil); err != nil {
+		t.Fatalf("Failed to set up tracing: %v", err)
+	}
+	return recorder
+}
+
+// spanAttribute returns the value of the attribute key on span
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+// TestTracing tests that requests get a server span with the queries they run as children
+func TestTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	createFeedJob(t, server.URL, "Go Developer")
+
+	var jobs []interface{}
+	if status := getJSON(t, server.URL+"/jobs?query=Go", &jobs); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+
+	var request sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /jobs" {
+			request = span
+		}
+	}
+	if request == nil {
+		t.Fatalf("Expected a span named GET /jobs")
+	}
+	if request.SpanKind() != trace.SpanKindServer || spanAttribute(request, "http.response.status_code") != "200" {
+		t.Errorf("Expected a server span with status 200, got %v and %v", request.SpanKind(), request.Attributes())
+	}
+
+	// Both the count and the page query run under the request
+	var queries []string
+	for _, span := range recorder.Ended() {
+		if span.Parent().SpanID() == request.SpanContext().SpanID() {
+			if query := spanAttribute(span, "db.statement"); query != "" {
+				queries = append(queries, query)
+			}
+		}
+	}
+	var counted, paged bool
+	for _, query := range queries {
+		counted = counted || strings.Contains(query, "COUNT(*)")
+		paged = paged || strings.Contains(query, "LIMIT")
+	}
+	if !counted || !paged {
+		t.Errorf("Expected the count and page queries as children of the request, got %v", queries)
+	}
+}
+
+// TestTracingEndUser tests that spans name the user only when the gateway vouched for them
+func TestTracingEndUser(t *testing.T) {
+	recorder := recordSpans(t)
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	forged, _ := http.NewRequest("GET", server.URL+"/jobs", nil)
+	forged.Header.Set("X-User-ID", "mallory")
+	verified, _ := http.NewRequest("GET", server.URL+"/jobs", nil)
+	Authenticate(verified, "alice", "employer")
+
+	for _, req := range []*http.Request{forged, verified} {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var users []string
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /jobs" {
+			users = append(users, spanAttribute(span, "enduser.id"))
+		}
+	}
+	if len(users) != 2 || users[0] != "" || users[1] != "alice" {
+		t.Errorf("Expected only the verified user on the spans, got %q", users)
+	}
+}
+
+// TestTracingContinuesTrace tests that a traceparent sent by the caller is continued
+func TestTracingContinuesTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, _ := http.NewRequest("GET", server.URL+"/jobs", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) == 0 {
+		t.Fatalf("Expected spans to be recorded")
+	}
+	for _, span := range spans {
+		if span.SpanContext().TraceID().String() != traceID {
+			t.Errorf("Expected span %q to be in trace %s, got %s", span.Name(), traceID, span.SpanContext().TraceID())
+		}
+	}
+}
+
+// TestTracingStdoutExporter tests that the stdout exporter writes spans when flushed
+func TestTracingStdoutExporter(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	var out bytes.Buffer
+	cfg := config.Default().Tracing
+	cfg.Exporter = "stdout"
+
+	shutdown, err := tracing.Setup(context.Background(), cfg, &out)
+	if err != nil {
+		t.Fatalf("Failed to set up tracing: %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "checkout")
+	span.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to flush spans: %v", err)
+	}
+	if !strings.Contains(out.String(), `"Name":"checkout"`) || !strings.Contains(out.String(), `"Value":"job-board"`) {
+		t.Errorf("Expected the span and service name to be written, got %s", out.String())
+	}
+}
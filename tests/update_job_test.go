@@ -23,7 +23,7 @@ func TestUpdateJob(t *testing.T) {
 	}
 
 	body, _ := json.Marshal(job)
-	createResp, err := http.Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	createResp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		t.Fatalf("Failed to create job: %v", err)
 	}
@@ -47,6 +47,7 @@ func TestUpdateJob(t *testing.T) {
 
 	updateBody, _ := json.Marshal(updatedJob)
 	req, _ := http.NewRequest("PUT", server.URL+"/jobs/"+jobID, bytes.NewBuffer(updateBody))
+	Authenticate(req, TestEmployer, "employer")
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{}
@@ -69,15 +70,8 @@ func TestUpdateJob(t *testing.T) {
 		t.Errorf("Expected 'job updated successfully', got '%v'", result["message"])
 	}
 
-	// Fetch the updated job to verify
-	getResp, err := http.Get(server.URL + "/jobs/" + jobID)
-	if err != nil {
-		t.Fatalf("Failed to fetch job: %v", err)
-	}
-	defer getResp.Body.Close()
-
-	var fetchedJob map[string]interface{}
-	json.NewDecoder(getResp.Body).Decode(&fetchedJob)
+	// Fetch the updated job to verify, as its owner since it is a draft
+	_, fetchedJob := doWithRole(t, TestEmployer, "employer", "GET", server.URL+"/jobs/"+jobID, nil)
 
 	if fetchedJob["title"] != updatedJob["title"] {
 		t.Errorf("Expected title '%s', got '%s'", updatedJob["title"], fetchedJob["title"])
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fieldCodes maps each field of a validation error response to its code
+func fieldCodes(t *testing.T, resp *http.Response) map[string]string {
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	codes := map[string]string{}
+	for _, fieldError := range result["errors"].([]interface{}) {
+		fieldError := fieldError.(map[string]interface{})
+		codes[fieldError["field"].(string)] = fieldError["code"].(string)
+	}
+	return codes
+}
+
+// TestValidation_CreateJob tests that every invalid field is reported at once
+func TestValidation_CreateJob(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	job := map[string]interface{}{
+		"title":       strings.Repeat("a", 201),
+		"description": "Build APIs",
+		"location":    "  ",
+		"salary":      -100.0,
+		"duties":      []string{"Write code", ""},
+		"url":         "example.com/job",
+	}
+
+	body, _ := json.Marshal(job)
+	resp, err := Post(server.URL+"/jobs", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	codes := fieldCodes(t, resp)
+	expected := map[string]string{
+		"title":     "too_long",
+		"location":  "required",
+		"salary":    "too_small",
+		"duties[1]": "required",
+		"url":       "invalid_url",
+	}
+
+	if len(codes) != len(expected) {
+		t.Errorf("Expected %d field errors, got %v", len(expected), codes)
+	}
+	for field, code := range expected {
+		if codes[field] != code {
+			t.Errorf("Expected %s to fail with %s, got %q", field, code, codes[field])
+		}
+	}
+}
+
+// TestValidation_UpdateJob tests that updates are validated like new jobs
+func TestValidation_UpdateJob(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	duties := make([]string, 51)
+	for i := range duties {
+		duties[i] = "Write code"
+	}
+
+	job := map[string]interface{}{
+		"title":       "Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      120000.0,
+		"duties":      duties,
+	}
+
+	body, _ := json.Marshal(job)
+	req, _ := http.NewRequest("PUT", server.URL+"/jobs/"+jobID, bytes.NewBuffer(body))
+	Authenticate(req, TestEmployer, "employer")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	codes := fieldCodes(t, resp)
+	if codes["duties"] != "too_many" {
+		t.Errorf("Expected duties to fail with too_many, got %v", codes)
+	}
+
+	// Malformed JSON is still a bad request
+	req, _ = http.NewRequest("PUT", server.URL+"/jobs/"+jobID, strings.NewReader("{"))
+	Authenticate(req, TestEmployer, "employer")
+	req.Header.Set("Content-Type", "application/json")
+
+	badResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer badResp.Body.Close()
+
+	if badResp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", badResp.StatusCode)
+	}
+}
+
+// TestValidation_ImportJobs tests that import rows carry the same field errors
+func TestValidation_ImportJobs(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	ndjson := `{"title":"Backend Developer","description":"Build APIs","location":"Lagos","salary":120000,"duties":["Code"],"url":"ftp://example.com"}` + "\n"
+
+	resp, err := Post(server.URL+"/jobs/import", "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	rowError := result["errors"].([]interface{})[0].(map[string]interface{})
+	fields := rowError["fields"].([]interface{})
+	fieldError := fields[0].(map[string]interface{})
+
+	if fieldError["field"] != "url" || fieldError["code"] != "invalid_url" {
+		t.Errorf("Expected an invalid_url error on url, got %v", fieldError)
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	"github.com/Ademayowa/job-board/internal/handlers"
+	"github.com/gin-gonic/gin"
+)
+
+// TestAPIVersions_Deprecation tests that the legacy routes announce their deprecation and successor
+func TestAPIVersions_Deprecation(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	resp, err := http.Get(server.URL + "/api/v1/jobs")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Deprecation") != "" {
+		t.Errorf("Expected /api/v1 to answer without deprecation, got %d and %q", resp.StatusCode, resp.Header.Get("Deprecation"))
+	}
+
+	resp, err = http.Get(server.URL + "/jobs?page=2")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	api := config.Default().API
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the legacy route to keep working, got %d", resp.StatusCode)
+	}
+	if deprecation := resp.Header.Get("Deprecation"); deprecation != "@"+strconv.FormatInt(api.LegacyDeprecated.Unix(), 10) {
+		t.Errorf("Expected the deprecation date, got %q", deprecation)
+	}
+	sunset, err := http.ParseTime(resp.Header.Get("Sunset"))
+	if err != nil || !sunset.Equal(api.LegacySunset) {
+		t.Errorf("Expected the sunset date %s, got %q", api.LegacySunset, resp.Header.Get("Sunset"))
+	}
+	if link := resp.Header.Get("Link"); link != `</api/v1/jobs>; rel="successor-version"` {
+		t.Errorf("Expected a link to the successor route, got %q", link)
+	}
+}
+
+// TestAPIVersions_V2 tests that v2 represents the same job differently from v1
+func TestAPIVersions_V2(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createFeedJob(t, server.URL, "Go Developer")
+
+	v1 := map[string]interface{}{}
+	if status := getJSON(t, server.URL+"/api/v1/jobs/"+jobID, &v1); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if v1["salary"] != 120000.0 || v1["description"] != "Build APIs" {
+		t.Errorf("Expected the v1 representation, got %v", v1)
+	}
+
+	v2 := map[string]interface{}{}
+	if status := getData(t, server.URL+"/api/v2/jobs/"+jobID, &v2); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+
+	salary, _ := v2["salary"].(map[string]interface{})
+	if salary["amount"] != 120000.0 || salary["currency"] != "USD" || salary["unit"] != "YEAR" {
+		t.Errorf("Expected the salary with its currency, got %v", v2["salary"])
+	}
+	description, _ := v2["description"].(map[string]interface{})
+	if description["markdown"] != "Build APIs" || !strings.Contains(description["html"].(string), "<p>Build APIs</p>") {
+		t.Errorf("Expected the description with its HTML, got %v", v2["description"])
+	}
+	if v2["apply_url"] != v1["url"] || v2["expires_at"] == "" || v2["url"] != nil {
+		t.Errorf("Expected apply_url and expires_at in place of url, got %v", v2)
+	}
+
+	// Lists use the same representation
+	list := map[string]interface{}{}
+	getJSON(t, server.URL+"/api/v2/jobs", &list)
+	jobs := list["data"].([]interface{})
+	if len(jobs) != 1 {
+		t.Fatalf("Expected one job, got %d", len(jobs))
+	}
+	if _, ok := jobs[0].(map[string]interface{})["salary"].(map[string]interface{}); !ok {
+		t.Errorf("Expected listed jobs in the v2 representation, got %v", jobs[0])
+	}
+}
+
+// TestAPIVersions_SharedRateLimit tests that every version of a route counts against the same limit
+func TestAPIVersions_SharedRateLimit(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	// Imports are limited to 5 a minute
+	paths := []string{"/jobs/import", "/api/v1/jobs/import", "/api/v2/jobs/import", "/jobs/import", "/api/v1/jobs/import", "/api/v2/jobs/import"}
+	for i, path := range paths {
+		req, _ := http.NewRequest("POST", server.URL+path+"?format=ndjson&dry_run=true", strings.NewReader(""))
+		Authenticate(req, "alice", "employer")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		limited := resp.StatusCode == http.StatusTooManyRequests
+		if limited != (i == 5) {
+			t.Errorf("Request %d to %s: expected limited to be %v, got status %d", i+1, path, i == 5, resp.StatusCode)
+		}
+	}
+}
+
+// TestAPIVersions_LegacyRoutesOff tests that the legacy routes can be turned off
+func TestAPIVersions_LegacyRoutesOff(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	cfg := config.Default()
+	cfg.API.LegacyRoutes = false
+
+	router := gin.New()
+	handlers.RegisterRoutes(router, cfg, handlers.Dependencies{})
+	versioned := httptest.NewServer(router)
+	defer versioned.Close()
+
+	var result interface{}
+	if status := getJSON(t, versioned.URL+"/jobs", &result); status != http.StatusNotFound {
+		t.Errorf("Expected the legacy route to be gone, got %d", status)
+	}
+	if status := getJSON(t, versioned.URL+"/api/v1/jobs", &result); status != http.StatusOK {
+		t.Errorf("Expected /api/v1 to keep working, got %d", status)
+	}
+
+	// A sunset must come after the deprecation
+	cfg = config.Default()
+	cfg.API.LegacySunset = cfg.API.LegacyDeprecated.Add(-24 * time.Hour)
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "api.legacy_sunset") {
+		t.Errorf("Expected a sunset before the deprecation to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,384 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ademayowa/job-board/internal/config"
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/models"
+	"github.com/Ademayowa/job-board/internal/webhooks"
+)
+
+// receivedWebhook is a delivery captured by the test receiver
+type receivedWebhook struct {
+	Header http.Header
+	Body   []byte
+}
+
+// newWebhookReceiver starts a server that records deliveries and
+// responds with the given status code
+func newWebhookReceiver(status int) (*httptest.Server, func() []receivedWebhook) {
+	var mu sync.Mutex
+	var received []receivedWebhook
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedWebhook{Header: r.Header, Body: body})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+
+	return server, func() []receivedWebhook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedWebhook(nil), received...)
+	}
+}
+
+// setupWebhookApp sets up the test environment letting webhooks target
+// the local test receivers
+func setupWebhookApp(t *testing.T) *httptest.Server {
+	return SetupTestAppWith(t, func(cfg *config.Config) {
+		cfg.Webhooks.AllowPrivateTargets = true
+	})
+}
+
+// newLocalDispatcher returns a dispatcher that can reach the local test
+// receivers
+func newLocalDispatcher() *webhooks.Dispatcher {
+	dispatcher := webhooks.NewDispatcher()
+	dispatcher.Client = &http.Client{Timeout: 10 * time.Second}
+	return dispatcher
+}
+
+// createWebhook subscribes the URL to the events as an admin and returns
+// the webhook
+func createWebhook(t *testing.T, serverURL, url string, events []string) map[string]interface{} {
+	resp, result := doWithRole(t, "root", "admin", "POST", serverURL+"/webhooks", map[string]interface{}{"url": url, "events": events})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	return result["webhook"].(map[string]interface{})
+}
+
+// getAsAdmin fetches a URL as an admin and decodes its JSON response
+func getAsAdmin(t *testing.T, url string, v interface{}) int {
+	req, _ := http.NewRequest("GET", url, nil)
+	Authenticate(req, "root", "admin")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	json.NewDecoder(resp.Body).Decode(v)
+	return resp.StatusCode
+}
+
+// getDeliveries fetches the delivery log of a webhook
+func getDeliveries(t *testing.T, serverURL, webhookID string) []map[string]interface{} {
+	var deliveries []map[string]interface{}
+	getAsAdmin(t, serverURL+"/webhooks/"+webhookID+"/deliveries", &deliveries)
+	return deliveries
+}
+
+// TestWebhooks_DeliversSignedEvents tests that job events reach subscribers signed
+func TestWebhooks_DeliversSignedEvents(t *testing.T) {
+	server := setupWebhookApp(t)
+	defer Teardown(t, server)
+
+	receiver, received := newWebhookReceiver(http.StatusOK)
+	defer receiver.Close()
+
+	webhook := createWebhook(t, server.URL, receiver.URL, []string{"job.created"})
+	secret := webhook["secret"].(string)
+	if secret == "" {
+		t.Fatal("Webhook should be created with a secret")
+	}
+
+	jobID := createFeedJob(t, server.URL, "Backend Developer")
+
+	// Deleting the job emits job.deleted, which this webhook did not subscribe to
+	req, _ := http.NewRequest("DELETE", server.URL+"/jobs/"+jobID, nil)
+	Authenticate(req, TestEmployer, "employer")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to delete job: %v", err)
+	}
+	resp.Body.Close()
+
+	if err := newLocalDispatcher().RunOnce(context.Background()); err != nil {
+		t.Fatalf("Dispatcher failed: %v", err)
+	}
+
+	deliveries := received()
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(deliveries))
+	}
+
+	delivery := deliveries[0]
+	if delivery.Header.Get(webhooks.HeaderEvent) != "job.created" {
+		t.Errorf("Expected job.created event, got '%s'", delivery.Header.Get(webhooks.HeaderEvent))
+	}
+
+	timestamp, _ := strconv.ParseInt(delivery.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+	if !webhooks.Verify(secret, timestamp, delivery.Body, delivery.Header.Get(webhooks.HeaderSignature)) {
+		t.Error("Delivery signature does not verify")
+	}
+
+	var payload map[string]interface{}
+	json.Unmarshal(delivery.Body, &payload)
+	if payload["data"].(map[string]interface{})["id"] != jobID {
+		t.Errorf("Expected payload for job %s, got %v", jobID, payload["data"])
+	}
+
+	// The delivery log records the success
+	log := getDeliveries(t, server.URL, webhook["id"].(string))
+	if len(log) != 1 || log[0]["status"] != "succeeded" {
+		t.Errorf("Expected 1 succeeded delivery, got %v", log)
+	}
+
+	// Secrets are never returned after creation
+	var fetched map[string]interface{}
+	getAsAdmin(t, server.URL+"/webhooks/"+webhook["id"].(string), &fetched)
+	if _, ok := fetched["secret"]; ok {
+		t.Error("Fetched webhook should not include the secret")
+	}
+}
+
+// TestWebhooks_RetriesAndRedelivers tests retry scheduling and manual redelivery
+func TestWebhooks_RetriesAndRedelivers(t *testing.T) {
+	server := setupWebhookApp(t)
+	defer Teardown(t, server)
+
+	receiver, received := newWebhookReceiver(http.StatusInternalServerError)
+	defer receiver.Close()
+
+	webhook := createWebhook(t, server.URL, receiver.URL, []string{"job.created"})
+	webhookID := webhook["id"].(string)
+
+	createFeedJob(t, server.URL, "Backend Developer")
+
+	dispatcher := newLocalDispatcher()
+	if err := dispatcher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("Dispatcher failed: %v", err)
+	}
+
+	log := getDeliveries(t, server.URL, webhookID)
+	if len(log) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(log))
+	}
+
+	delivery := log[0]
+	if delivery["status"] != "pending" || delivery["attempts"] != float64(1) || delivery["response_status"] != float64(500) {
+		t.Errorf("Expected a pending delivery after 1 failed attempt, got %v", delivery)
+	}
+
+	// The retry is scheduled in the future, so running again sends nothing
+	nextAttempt, _ := time.Parse(time.RFC3339, delivery["next_attempt_at"].(string))
+	if !nextAttempt.After(time.Now()) {
+		t.Errorf("Expected the next attempt to be scheduled in the future, got %s", nextAttempt)
+	}
+
+	dispatcher.RunOnce(context.Background())
+	if len(received()) != 1 {
+		t.Errorf("Expected no retry before the backoff elapses, got %d deliveries", len(received()))
+	}
+
+	// A manual redelivery is sent straight away
+	resp, _ := doWithRole(t, "root", "admin", "POST", server.URL+"/webhooks/"+webhookID+"/deliveries/"+delivery["id"].(string)+"/redeliver", nil)
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", resp.StatusCode)
+	}
+
+	dispatcher.RunOnce(context.Background())
+	if len(received()) != 2 {
+		t.Errorf("Expected the redelivery to be sent, got %d deliveries", len(received()))
+	}
+}
+
+// TestWebhooks_Backoff tests the exponential retry delay
+func TestWebhooks_Backoff(t *testing.T) {
+	dispatcher := webhooks.NewDispatcher()
+	dispatcher.BaseDelay = time.Second
+	dispatcher.MaxDelay = 10 * time.Second
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for i, want := range expected {
+		if got := dispatcher.Backoff(i + 1); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+// TestWebhooks_AdminOnly tests that only admins can manage webhooks
+func TestWebhooks_AdminOnly(t *testing.T) {
+	server := setupWebhookApp(t)
+	defer Teardown(t, server)
+
+	webhook := createWebhook(t, server.URL, "https://hooks.example.com/jobs", []string{"job.created"})
+	webhookURL := server.URL + "/webhooks/" + webhook["id"].(string)
+
+	requests := []struct{ method, url string }{
+		{"GET", server.URL + "/webhooks"},
+		{"POST", server.URL + "/webhooks"},
+		{"GET", webhookURL},
+		{"PUT", webhookURL},
+		{"DELETE", webhookURL},
+		{"GET", webhookURL + "/deliveries"},
+	}
+	body := map[string]interface{}{"url": "https://attacker.example.com", "events": []string{"job.created"}}
+
+	for _, request := range requests {
+		resp, _ := doWithRole(t, "carol", "reviewer", request.method, request.url, body)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s %s: expected a reviewer to get status 403, got %d", request.method, request.url, resp.StatusCode)
+		}
+
+		req, _ := http.NewRequest(request.method, request.url, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected an anonymous request to get status 401, got %d", request.method, request.url, resp.StatusCode)
+		}
+	}
+}
+
+// TestWebhooks_RejectsPrivateTargets tests that webhooks can't make the
+// server call itself or its network
+func TestWebhooks_RejectsPrivateTargets(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	targets := []string{
+		"http://hooks.example.com/jobs",
+		"https://127.0.0.1/hook",
+		"https://localhost/hook",
+		"https://10.0.0.8/hook",
+		"https://192.168.1.1/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https://0.0.0.0/hook",
+	}
+
+	for _, target := range targets {
+		resp, result := doWithRole(t, "root", "admin", "POST", server.URL+"/webhooks", map[string]interface{}{"url": target, "events": []string{"job.created"}})
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected status 422, got %d %v", target, resp.StatusCode, result)
+		}
+	}
+}
+
+// TestWebhooks_DeliveryChecksAddress tests that deliveries aren't sent to
+// private addresses even if the target passed the check when it was saved
+func TestWebhooks_DeliveryChecksAddress(t *testing.T) {
+	server := setupWebhookApp(t)
+	defer Teardown(t, server)
+
+	receiver, received := newWebhookReceiver(http.StatusOK)
+	defer receiver.Close()
+
+	webhook := createWebhook(t, server.URL, receiver.URL, []string{"job.created"})
+	createFeedJob(t, server.URL, "Backend Developer")
+
+	if err := webhooks.NewDispatcher().RunOnce(context.Background()); err != nil {
+		t.Fatalf("Dispatcher failed: %v", err)
+	}
+
+	if len(received()) != 0 {
+		t.Errorf("Expected nothing to be sent to a loopback address, got %d deliveries", len(received()))
+	}
+
+	log := getDeliveries(t, server.URL, webhook["id"].(string))
+	if len(log) != 1 || !strings.Contains(log[0]["last_error"].(string), "not a public address") {
+		t.Errorf("Expected the delivery to fail on the address, got %v", log)
+	}
+}
+
+// outboxEvents returns the events queued for a job, oldest first
+func outboxEvents(t *testing.T, jobID string) []string {
+	rows, err := db.DB.Query("SELECT event FROM webhook_outbox WHERE job_id = ? ORDER BY rowid", jobID)
+	if err != nil {
+		t.Fatalf("Failed to read the outbox: %v", err)
+	}
+	defer rows.Close()
+
+	events := []string{}
+	for rows.Next() {
+		var event string
+		rows.Scan(&event)
+		events = append(events, event)
+	}
+	return events
+}
+
+// TestWebhooks_OnlyPublicJobs tests that subscribers only hear about jobs
+// the public can see
+func TestWebhooks_OnlyPublicJobs(t *testing.T) {
+	server := setupWebhookApp(t)
+	defer Teardown(t, server)
+
+	edit := map[string]interface{}{
+		"title":       "Senior Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      150000.0,
+		"duties":      []string{"Write code"},
+	}
+
+	// Drafts are private
+	jobID := createDraftJob(t, server.URL, "alice")
+	doWithRole(t, "alice", "employer", "PUT", server.URL+"/jobs/"+jobID, edit)
+	if events := outboxEvents(t, jobID); len(events) != 0 {
+		t.Errorf("Expected no events for a draft, got %v", events)
+	}
+
+	// Publishing creates the job for subscribers, and an edit sent back to
+	// review takes it away again
+	PublishJob(t, server.URL, jobID)
+	edit["title"] = "Lead Backend Developer"
+	doWithRole(t, "alice", "employer", "PUT", server.URL+"/jobs/"+jobID, edit)
+
+	events := outboxEvents(t, jobID)
+	if strings.Join(events, ",") != "job.created,job.deleted" {
+		t.Errorf("Expected the job to be created then deleted for subscribers, got %v", events)
+	}
+
+	// Only published jobs that aren't hidden expire
+	old := time.Now().AddDate(0, 0, -30).UTC().Format(time.RFC3339)
+	draftID := createDraftJob(t, server.URL, "bob")
+	hiddenID := createPublishedJob(t, server.URL, "bob", "Data Analyst")
+	expiredID := createPublishedJob(t, server.URL, "bob", "Product Designer")
+	db.DB.Exec("UPDATE jobs SET created_at = ?, published_at = ? WHERE owner_id = 'bob'", old, old)
+	db.DB.Exec("UPDATE jobs SET hidden = 1 WHERE id = ?", hiddenID)
+
+	if _, err := models.EnqueueExpiredJobEvents(context.Background()); err != nil {
+		t.Fatalf("Failed to queue expiry events: %v", err)
+	}
+
+	for _, id := range []string{draftID, hiddenID} {
+		if events := outboxEvents(t, id); slices.Contains(events, "job.expired") {
+			t.Errorf("Expected no expiry event for a job the public can't see, got %v", events)
+		}
+	}
+	if events := outboxEvents(t, expiredID); !slices.Contains(events, "job.expired") {
+		t.Errorf("Expected an expiry event for the published job, got %v", events)
+	}
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/Ademayowa/job-board/internal/database"
+	"github.com/Ademayowa/job-board/internal/scheduler"
+)
+
+// TestWorkflow_DraftIsPrivate tests that drafts are only visible to their owner and staff
+func TestWorkflow_DraftIsPrivate(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+
+	resp, _ := doWithRole(t, "alice", "employer", "GET", server.URL+"/jobs/"+jobID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the owner to see their draft, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doWithRole(t, "bob", "employer", "GET", server.URL+"/jobs/"+jobID, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected another employer to get 404, got %d", resp.StatusCode)
+	}
+
+	resp, _ = doWithRole(t, "carol", "reviewer", "GET", server.URL+"/jobs/"+jobID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected a reviewer to see the draft, got %d", resp.StatusCode)
+	}
+
+	_, list := doWithRole(t, "alice", "employer", "GET", server.URL+"/jobs", nil)
+	metadata := list["metadata"].(map[string]interface{})
+	if metadata["total"] != float64(0) {
+		t.Errorf("Expected drafts to be left out of the job list, got total %v", metadata["total"])
+	}
+}
+
+// TestWorkflow_ReviewAndClose tests the full path from draft to closed
+func TestWorkflow_ReviewAndClose(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+	jobURL := server.URL + "/jobs/" + jobID
+
+	// Only the owner may submit their job
+	resp, _ := doWithRole(t, "bob", "employer", "POST", jobURL+"/submit", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected another employer to get 403, got %d", resp.StatusCode)
+	}
+
+	resp, result := doWithRole(t, "alice", "employer", "POST", jobURL+"/submit", nil)
+	if resp.StatusCode != http.StatusOK || result["job"].(map[string]interface{})["status"] != "pending_review" {
+		t.Fatalf("Expected the job to be pending review, got %d %v", resp.StatusCode, result)
+	}
+
+	// Employers can't approve their own jobs
+	resp, _ = doWithRole(t, "alice", "employer", "POST", jobURL+"/approve", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected an employer approving to get 403, got %d", resp.StatusCode)
+	}
+
+	// A rejection must say why
+	resp, _ = doWithRole(t, "carol", "reviewer", "POST", jobURL+"/reject", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a rejection without a comment to get 400, got %d", resp.StatusCode)
+	}
+
+	resp, result = doWithRole(t, "carol", "reviewer", "POST", jobURL+"/reject", map[string]interface{}{"comment": "Add a salary range"})
+	rejected := result["job"].(map[string]interface{})
+	if resp.StatusCode != http.StatusOK || rejected["status"] != "draft" || rejected["review_comment"] != "Add a salary range" {
+		t.Fatalf("Expected the job to be back in draft with a comment, got %d %v", resp.StatusCode, result)
+	}
+
+	doWithRole(t, "alice", "employer", "POST", jobURL+"/submit", nil)
+
+	resp, result = doWithRole(t, "carol", "reviewer", "POST", jobURL+"/approve", nil)
+	if resp.StatusCode != http.StatusOK || result["job"].(map[string]interface{})["status"] != "published" {
+		t.Fatalf("Expected the job to be published, got %d %v", resp.StatusCode, result)
+	}
+
+	// Published jobs are public
+	resp, _ = doWithRole(t, "bob", "employer", "GET", jobURL, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected a published job to be public, got %d", resp.StatusCode)
+	}
+
+	// A published job can't be submitted again
+	resp, _ = doWithRole(t, "alice", "employer", "POST", jobURL+"/submit", nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected an invalid transition to get 409, got %d", resp.StatusCode)
+	}
+
+	resp, result = doWithRole(t, "alice", "employer", "POST", jobURL+"/close", nil)
+	if resp.StatusCode != http.StatusOK || result["job"].(map[string]interface{})["status"] != "closed" {
+		t.Errorf("Expected the job to be closed, got %d %v", resp.StatusCode, result)
+	}
+}
+
+// TestWorkflow_EditPublished tests that only the owner and admins may
+// change a published job, and that edits go back through review
+func TestWorkflow_EditPublished(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+	PublishJob(t, server.URL, jobID)
+
+	edit := map[string]interface{}{
+		"title":       "Senior Backend Developer",
+		"description": "Build APIs",
+		"location":    "Lagos",
+		"salary":      150000.0,
+		"duties":      []string{"Write code"},
+	}
+
+	for _, role := range []string{"employer", "reviewer"} {
+		resp, _ := doWithRole(t, "mallory", role, "PUT", server.URL+"/jobs/"+jobID, edit)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected a %s who doesn't own the job to get 403 editing it, got %d", role, resp.StatusCode)
+		}
+
+		resp, _ = doWithRole(t, "mallory", role, "DELETE", server.URL+"/jobs/"+jobID, nil)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected a %s who doesn't own the job to get 403 deleting it, got %d", role, resp.StatusCode)
+		}
+	}
+
+	resp, _ := doWithRole(t, "alice", "employer", "PUT", server.URL+"/jobs/"+jobID, edit)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the owner to edit the job, got %d", resp.StatusCode)
+	}
+
+	_, job := doWithRole(t, "alice", "employer", "GET", server.URL+"/jobs/"+jobID, nil)
+	if job["status"] != "pending_review" || job["title"] != edit["title"] {
+		t.Errorf("Expected the edit to go back to review, got %v %q", job["status"], job["title"])
+	}
+
+	// The unreviewed edit isn't listed
+	if status := getJSON(t, server.URL+"/jobs/"+jobID, &job); status != http.StatusNotFound {
+		t.Errorf("Expected the job to be hidden until approved, got %d", status)
+	}
+
+	resp, _ = doWithRole(t, "root", "admin", "DELETE", server.URL+"/jobs/"+jobID, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected an admin to delete the job, got %d", resp.StatusCode)
+	}
+}
+
+// TestWorkflow_ScheduledPublishing tests that approved jobs with a future
+// publish time go live once the scheduler sees they are due
+func TestWorkflow_ScheduledPublishing(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+	jobURL := server.URL + "/jobs/" + jobID
+
+	doWithRole(t, "alice", "employer", "POST", jobURL+"/submit", nil)
+
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	resp, result := doWithRole(t, "carol", "reviewer", "POST", jobURL+"/approve", map[string]interface{}{"publish_at": publishAt})
+	if resp.StatusCode != http.StatusOK || result["job"].(map[string]interface{})["status"] != "scheduled" {
+		t.Fatalf("Expected the job to be scheduled, got %d %v", resp.StatusCode, result)
+	}
+
+	// Nothing is due yet
+	publisher := scheduler.NewPublisher()
+	if err := publisher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("Publisher failed: %v", err)
+	}
+
+	_, result = doWithRole(t, "carol", "reviewer", "GET", jobURL, nil)
+	if result["status"] != "scheduled" {
+		t.Errorf("Expected the job to stay scheduled, got %v", result["status"])
+	}
+
+	// Move the publish time into the past
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	if _, err := db.DB.Exec("UPDATE jobs SET publish_at = ? WHERE id = ?", past, jobID); err != nil {
+		t.Fatalf("Failed to update publish time: %v", err)
+	}
+
+	if err := publisher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("Publisher failed: %v", err)
+	}
+
+	_, result = doWithRole(t, "bob", "employer", "GET", jobURL, nil)
+	if result["status"] != "published" {
+		t.Errorf("Expected the job to be published, got %v", result["status"])
+	}
+	if result["published_at"] == nil || result["expired"] != false {
+		t.Errorf("Expected the job to be open from when it was published, got %v and expired %v", result["published_at"], result["expired"])
+	}
+}
+
+// TestWorkflow_ExpiryCountsFromPublication tests that a job stays open for
+// as long after it is published however long it took to get there
+func TestWorkflow_ExpiryCountsFromPublication(t *testing.T) {
+	server := SetupTestApp(t)
+	defer Teardown(t, server)
+
+	jobID := createDraftJob(t, server.URL, "alice")
+	created := time.Now().AddDate(0, 0, -20).UTC().Format(time.RFC3339)
+	if _, err := db.DB.Exec("UPDATE jobs SET created_at = ? WHERE id = ?", created, jobID); err != nil {
+		t.Fatalf("Failed to update creation time: %v", err)
+	}
+	PublishJob(t, server.URL, jobID)
+
+	var job map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID, &job)
+	if job["expired"] != false {
+		t.Errorf("Expected a job published today to be open, got expired %v", job["expired"])
+	}
+
+	var posting map[string]interface{}
+	getJSON(t, server.URL+"/jobs/"+jobID+"/jsonld", &posting)
+
+	datePosted, err := time.Parse(time.RFC3339, posting["datePosted"].(string))
+	if err != nil || time.Since(datePosted) > time.Minute {
+		t.Errorf("Expected the job to be posted when it was published, got %v", posting["datePosted"])
+	}
+	validThrough, err := time.Parse(time.RFC3339, posting["validThrough"].(string))
+	if err != nil || !validThrough.Equal(datePosted.AddDate(0, 0, 14)) {
+		t.Errorf("Expected validThrough 14 days after publication, got %v", posting["validThrough"])
+	}
+
+	resp, err := http.Get(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), jobID) {
+		t.Errorf("Expected the job in the sitemap, got %s", body)
+	}
+}